@@ -72,7 +72,7 @@ swag init \
   --parseDependency \
   --parseInternal \
   --generalInfo "main.go" \
-  --dir "./,../../internal/auth,../../internal/engine,../../internal/health,../../internal/hello,../../internal/httpserver,../../internal/logic" \
+  --dir "./,../../internal/admin,../../internal/auth,../../internal/batch,../../internal/dataset,../../internal/engine,../../internal/health,../../internal/hello,../../internal/history,../../internal/httpcache,../../internal/httpserver,../../internal/jobs,../../internal/logic,../../internal/pipeline" \
   --exclude "../gen,../docs,../tmp,../vendor" \
   --output "../../docs"
 
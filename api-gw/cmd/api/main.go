@@ -4,21 +4,37 @@ import (
 	"context"
 	"database/sql"
 	"log"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	_ "github.com/Patrick8894/harmonia/api-gw/docs"
 	_ "github.com/go-sql-driver/mysql"
 
+	"github.com/Patrick8894/harmonia/api-gw/internal/admin"
 	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
 	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
 	"github.com/Patrick8894/harmonia/api-gw/internal/config"
+	"github.com/Patrick8894/harmonia/api-gw/internal/dataset"
 	"github.com/Patrick8894/harmonia/api-gw/internal/engine"
 	"github.com/Patrick8894/harmonia/api-gw/internal/health"
 	"github.com/Patrick8894/harmonia/api-gw/internal/hello"
+	"github.com/Patrick8894/harmonia/api-gw/internal/history"
 	"github.com/Patrick8894/harmonia/api-gw/internal/httpserver"
+	"github.com/Patrick8894/harmonia/api-gw/internal/jobs"
+	"github.com/Patrick8894/harmonia/api-gw/internal/logging"
 	"github.com/Patrick8894/harmonia/api-gw/internal/logic"
+	"github.com/Patrick8894/harmonia/api-gw/internal/pipeline"
+	"github.com/Patrick8894/harmonia/api-gw/internal/ratelimit"
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
+	"github.com/Patrick8894/harmonia/api-gw/internal/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -39,19 +55,52 @@ import (
 // @tag.name engine
 // @tag.description C++ Thrift EngineService
 
+// @tag.name admin
+// @tag.description Operator-only endpoints (cache inspection and invalidation)
+
+// @tag.name jobs
+// @tag.description Background jobs for long-running engine and logic operations
+
+// @tag.name pipeline
+// @tag.description Multi-step logic/engine pipelines
+
+// @tag.name history
+// @tag.description Per-user record of past engine and logic calls
+
+// @tag.name datasets
+// @tag.description Stored vectors and matrices, referenced by ID from engine and logic requests
+
 // @tag.name health
 // @tag.description Liveness & readiness
 
 func main() {
 	cfg := config.Load()
+	if err := logging.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatal(err)
+	}
+	for _, u := range cfg.AdminUsers {
+		if auth.IsDevUser(u) {
+			slog.Error("ADMIN_USERS lists a seeded dev account, whose password is well known", "user", u)
+			os.Exit(1)
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("tracing setup", err)
+	}
 
 	// --- DB (users)
 	db, err := sql.Open("mysql", cfg.DBDSN)
 	if err != nil {
-		log.Fatal(err)
+		fatal("open mysql", err)
 	}
 	if err := db.Ping(); err != nil {
-		log.Fatal(err)
+		fatal("ping mysql", err)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -59,58 +108,352 @@ func main() {
 
 	// Migrations + dev seed data
 	if err := auth.RunMigrations(ctx, db); err != nil {
-		log.Fatal(err)
+		fatal("run migrations", err)
 	}
 	if err := auth.SeedDevData(ctx, db); err != nil {
-		log.Fatal(err)
+		fatal("seed dev data", err)
+	}
+	if err := jobs.RunMigrations(ctx, db); err != nil {
+		fatal("run job migrations", err)
+	}
+	if err := history.RunMigrations(ctx, db); err != nil {
+		fatal("run history migrations", err)
+	}
+	if err := dataset.RunMigrations(ctx, db); err != nil {
+		fatal("run dataset migrations", err)
 	}
 
+	ready := health.NewReadiness(health.ReadinessConfig{
+		CacheFor: time.Duration(cfg.ReadyCacheMillis) * time.Millisecond,
+		Timeout:  time.Duration(cfg.ReadyTimeoutMillis) * time.Millisecond,
+		Critical: cfg.ReadyCritical,
+	})
+	ready.Add("mysql", db.PingContext)
+
+	// Closed in order on shutdown, after the HTTP server has drained.
+	var (
+		cacheClose   func() error
+		redisClients []*redis.Client
+	)
+
 	// --- Sessions backend selection
 	var sessStore auth.SessionStore
 	switch cfg.SessionBackend {
 	case "redis":
 		rdb := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
 		if err := rdb.Ping(context.Background()).Err(); err != nil {
-			log.Fatal(err)
+			fatal("ping redis (sessions)", err)
 		}
+		redisClients = append(redisClients, rdb)
 		sessStore = auth.NewRedisStore(rdb, time.Duration(cfg.CookieMaxAge)*time.Second, "sess:")
+		ready.Add("redis-sessions", func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
 	default:
 		sessStore = auth.NewMemoryStore(time.Duration(cfg.CookieMaxAge) * time.Second)
 	}
 
+	sessStore = auth.Instrumented(sessStore)
+
 	// --- Cache backend selection
 	var resultCache cache.Store
 	switch cfg.CacheBackend {
 	case "redis":
 		rdb := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
 		if err := rdb.Ping(context.Background()).Err(); err != nil {
-			log.Fatal(err)
+			fatal("ping redis (cache)", err)
 		}
+		redisClients = append(redisClients, rdb)
 		resultCache = cache.NewRedisStore(rdb, "rc:") // result cache namespace
+		ready.Add("redis-cache", func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
+	case "tiered":
+		rdb := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
+		if err := rdb.Ping(context.Background()).Err(); err != nil {
+			fatal("ping redis (cache)", err)
+		}
+		l1 := cache.NewLRUStore(cfg.CacheMaxEntries, cfg.CacheMaxBytes, time.Duration(cfg.CacheSweepSeconds)*time.Second)
+		tiered := cache.NewTieredStore(l1, cache.NewRedisStore(rdb, "rc:"), rdb, "rc:invalidate", time.Duration(cfg.CacheL1TTLSeconds)*time.Second)
+		redisClients = append(redisClients, rdb)
+		cacheClose = tiered.Close
+		resultCache = tiered
+		ready.Add("redis-cache", func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
+	case "lru":
+		lru := cache.NewLRUStore(cfg.CacheMaxEntries, cfg.CacheMaxBytes, time.Duration(cfg.CacheSweepSeconds)*time.Second)
+		cacheClose = lru.Close
+		resultCache = lru
 	default:
 		resultCache = cache.NewMemoryStore()
 	}
 
-	// Services (pass cache + TTL)
+	// Per-route cache policies (fresh TTL + stale window)
+	cachePolicies, err := cache.ParsePolicies(cfg.CachePolicies, cache.Policy{
+		TTL:      time.Duration(cfg.CacheTTLSeconds) * time.Second,
+		Stale:    time.Duration(cfg.CacheStaleSeconds) * time.Second,
+		Negative: time.Duration(cfg.CacheNegativeTTL) * time.Second,
+	})
+	if err != nil {
+		fatal("parse CACHE_POLICIES", err)
+	}
+	var cacheCodec cache.Codec = cache.BinaryCodec{}
+	if cfg.CacheCodec == "json" {
+		cacheCodec = cache.JSONCodec{}
+	}
+	cacheStats := cache.NewStatsStore(resultCache)
+	cacheStats.ExportMetrics()
+	resultLoader := cache.NewLoader(cacheStats, cachePolicies, cache.Encoding{Codec: cacheCodec, CompressAbove: cfg.CacheCompressMin})
+
+	// Services (pass cache loader)
 	userRepo := auth.NewUserRepo(db)
 	authCtrl := auth.NewController(userRepo, sessStore, cfg.CookieName, cfg.CookieDomain, cfg.CookieSecure, cfg.CookieMaxAge)
 
-	cacheTTL := time.Duration(cfg.CacheTTLSeconds) * time.Second
+	rpcResilience := resilience.Config{
+		Retry: resilience.RetryPolicy{
+			Attempts:  cfg.RPCRetryAttempts,
+			BaseDelay: time.Duration(cfg.RPCRetryBaseMillis) * time.Millisecond,
+			MaxDelay:  time.Duration(cfg.RPCRetryMaxMillis) * time.Millisecond,
+		},
+		Breaker: resilience.BreakerConfig{
+			Failures: cfg.BreakerFailures,
+			OpenFor:  time.Duration(cfg.BreakerOpenSeconds) * time.Second,
+		},
+	}
+
+	engineClient := engine.NewClient(cfg.EngineAddr, engine.PoolConfig{
+		MaxIdle:     cfg.EnginePoolMaxIdle,
+		MaxOpen:     cfg.EnginePoolMaxOpen,
+		IdleTimeout: time.Duration(cfg.EnginePoolIdleTimeoutSeconds) * time.Second,
+	}, engine.BalancerConfig{
+		Policy:       cfg.EngineLBPolicy,
+		ResolveEvery: time.Duration(cfg.EngineResolveSeconds) * time.Second,
+		HealthEvery:  time.Duration(cfg.EngineHealthSeconds) * time.Second,
+		EjectAfter:   cfg.EngineEjectAfterFailures,
+		EjectFor:     time.Duration(cfg.EngineEjectSeconds) * time.Second,
+	}, rpcResilience)
+	engineSvc := engine.NewService(engineClient, resultLoader, engine.AdmissionConfig{
+		MaxElements:   cfg.EngineMaxElements,
+		MaxPiSamples:  cfg.EngineMaxPiSamples,
+		MaxMatMulCost: cfg.EngineMaxMatMulCost,
+		HeavyCost:     cfg.EngineHeavyCost,
+		Concurrency: resilience.LimiterConfig{
+			MaxInFlight: cfg.EngineMaxInFlight,
+			MaxQueue:    cfg.EngineMaxQueue,
+			QueueWait:   time.Duration(cfg.EngineQueueWaitMillis) * time.Millisecond,
+		},
+	})
+	ready.Add("engine", engineSvc.Ping)
+
+	logicClient, err := logic.NewClient(cfg.LogicAddr, logic.KeepaliveConfig{
+		Time:    time.Duration(cfg.LogicKeepaliveTimeSeconds) * time.Second,
+		Timeout: time.Duration(cfg.LogicKeepaliveTimeoutSeconds) * time.Second,
+	}, logic.BalancerConfig{
+		Policy:      cfg.LogicLBPolicy,
+		HealthCheck: cfg.LogicHealthCheck,
+	}, rpcResilience)
+	if err != nil {
+		fatal("create logic client", err)
+	}
+	logicSvc := logic.NewService(logicClient, resultLoader)
+	ready.Add("logic", logicSvc.Ping)
+
+	// --- Background jobs
+	var jobStore jobs.Store
+	switch cfg.JobBackend {
+	case "redis":
+		rdb := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
+		if err := rdb.Ping(context.Background()).Err(); err != nil {
+			fatal("ping redis (jobs)", err)
+		}
+		redisClients = append(redisClients, rdb)
+		jobStore = jobs.NewRedisStore(rdb, "job:", time.Duration(cfg.JobRetentionHours)*time.Hour)
+		ready.Add("redis-jobs", func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
+	default:
+		jobStore = jobs.NewMySQLStore(db)
+	}
+	jobOps := engineSvc.JobOps()
+	for name, op := range logicSvc.JobOps() {
+		jobOps[name] = op
+	}
+	jobManager := jobs.NewManager(jobStore, jobOps, jobs.Config{
+		Workers:        cfg.JobWorkers,
+		QueueSize:      cfg.JobQueueSize,
+		DefaultTimeout: time.Duration(cfg.JobDefaultTimeoutSeconds) * time.Second,
+		MaxTimeout:     time.Duration(cfg.JobMaxTimeoutSeconds) * time.Second,
+		Retention:      time.Duration(cfg.JobRetentionHours) * time.Hour,
+		Heartbeat:      time.Duration(cfg.JobHeartbeatSeconds) * time.Second,
+	})
+	jobManager.Start()
+
+	pipelineRunner := pipeline.NewRunner(jobOps, cfg.PipelineMaxSteps)
 
-	engineClient := engine.NewClient(cfg.EngineAddr)
-	engineSvc := engine.NewService(engineClient, resultCache, cacheTTL)
+	// --- Datasets
+	var blobs dataset.Blobs
+	switch cfg.DatasetBackend {
+	case "disk":
+		disk, err := dataset.NewDiskBlobs(cfg.DatasetDir)
+		if err != nil {
+			fatal("open dataset dir", err)
+		}
+		blobs = disk
+	default:
+		blobs = dataset.NewMySQLBlobs(db)
+	}
+	datasets := dataset.NewStore(db, blobs)
+	if cfg.DatasetGCMinutes > 0 {
+		datasets.StartSweeper(time.Duration(cfg.DatasetGCMinutes)*time.Minute, time.Duration(cfg.DatasetGCGraceMins)*time.Minute)
+	}
 
-	logicClient := logic.NewClient(cfg.LogicAddr)
-	logicSvc := logic.NewService(logicClient, resultCache, cacheTTL)
+	// --- Computation history
+	historyStore := history.NewStore(db)
+	var recorder *history.Recorder
+	if cfg.HistoryEnabled {
+		recorder = history.NewRecorder(historyStore, history.Config{
+			MaxRequestBytes: cfg.HistoryMaxRequestBytes,
+			MaxResultBytes:  cfg.HistoryMaxResultBytes,
+			Retention:       time.Duration(cfg.HistoryRetentionDays) * 24 * time.Hour,
+			PurgeEvery:      time.Duration(cfg.HistoryPurgeMinutes) * time.Minute,
+		})
+		recorder.Start()
+	}
 
-	r := gin.Default()
+	// --- Rate limits and compute quota
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		userLimits, err := ratelimit.ParseLimits(cfg.RateLimits)
+		if err != nil {
+			fatal("parse RATE_LIMITS", err)
+		}
+		ipLimits, err := ratelimit.ParseLimits(cfg.RateLimitsIP)
+		if err != nil {
+			fatal("parse RATE_LIMITS_IP", err)
+		}
+		quota := ratelimit.PerWindow(cfg.ComputeQuotaPerHour, time.Hour)
+		if cfg.ComputeQuotaBurst > 0 {
+			quota.Burst = cfg.ComputeQuotaBurst
+		}
+
+		var rlStore ratelimit.Store
+		switch cfg.RateLimitBackend {
+		case "redis":
+			rdb := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr})
+			if err := rdb.Ping(context.Background()).Err(); err != nil {
+				fatal("ping redis (rate limits)", err)
+			}
+			redisClients = append(redisClients, rdb)
+			rlStore = ratelimit.NewRedisStore(rdb, "rl:")
+			ready.Add("redis-ratelimit", func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
+		default:
+			rlStore = ratelimit.NewMemoryStore()
+		}
+		costs := engine.CostRoutes()
+		maps.Copy(costs, logic.CostRoutes())
+		costs["jobs"] = jobs.SubmitCost(engine.CostRoutes())
+		costs["pipeline"] = pipeline.Cost(engine.CostRoutes())
+		limiter = &ratelimit.Limiter{
+			Store: rlStore,
+			User:  ratelimit.Policy{Name: "user", Key: ratelimit.ByUser, Limits: userLimits, Quota: quota, Costs: costs},
+			IP:    ratelimit.Policy{Name: "ip", Key: ratelimit.ByIP, Limits: ipLimits},
+		}
+	}
+
+	r := gin.New()
+	r.Use(gin.Recovery())
 	r.SetTrustedProxies(nil)
 
 	// Register routes; pass sessStore to middleware inside httpserver.RegisterRoutes
-	httpserver.RegisterRoutes(r, cfg, engineSvc, logicSvc, health.New(), hello.New(), authCtrl, sessStore)
+	jobsCtrl := jobs.NewController(jobManager)
+	adminCtrl := admin.New(cacheStats, resultLoader, engine.CacheRoutes(), logic.CacheRoutes())
 
-	log.Println("Harmonia API listening on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal(err)
+	httpserver.RegisterRoutes(r, cfg, engineSvc, logicSvc, health.New(ready, engineSvc.Breaker(), logicSvc.Breaker()), hello.New(), authCtrl, adminCtrl, jobsCtrl,
+		pipeline.NewController(pipelineRunner, time.Duration(cfg.PipelineTimeoutSeconds)*time.Second), history.NewController(historyStore, r),
+		dataset.NewController(datasets, dataset.Config{MaxBytes: cfg.DatasetMaxBytes, MaxElements: cfg.DatasetMaxElements}), sessStore, limiter, recorder, datasets)
+
+	srv := &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           r,
+		ReadHeaderTimeout: time.Duration(cfg.HTTPReadTimeoutSeconds) * time.Second,
+		ReadTimeout:       time.Duration(cfg.HTTPReadTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(cfg.HTTPWriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.HTTPIdleTimeoutSeconds) * time.Second,
+	}
+	// Shutdown waits for active requests; end job event streams so they
+	// don't run out the drain timeout.
+	srv.RegisterOnShutdown(jobsCtrl.Shutdown)
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("Harmonia API listening", "addr", cfg.HTTPAddr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	// Metrics on their own listener, reachable by the scraper but not
+	// exposed with the API
+	var metricsSrv *http.Server
+	if cfg.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		metricsSrv = &http.Server{Addr: cfg.MetricsAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			slog.Info("metrics listening", "addr", cfg.MetricsAddr)
+			serveErr <- metricsSrv.ListenAndServe()
+		}()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-serveErr:
+		fatal("serve", err)
+	case s := <-sig:
+		slog.Info("draining", "signal", s.String())
+	}
+	signal.Stop(sig)
+
+	// Fail /readyz first and give the load balancer a moment to notice, so
+	// new traffic stops arriving before the listener closes.
+	ready.SetDraining()
+	time.Sleep(time.Duration(cfg.ShutdownDelaySeconds) * time.Second)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownDrainSeconds)*time.Second)
+	defer drainCancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		slog.Warn("shutdown: drain incomplete", "err", err)
+	}
+	if metricsSrv != nil {
+		closeLogged("metrics", metricsSrv.Close)
 	}
+
+	// Jobs first (running ones are re-queued for the next start), queued
+	// history writes and the dataset sweeper, then the backends (nothing calls
+	// them any more), the stores their results and sessions live in, the
+	// Redis connections, and the DB last.
+	closeLogged("jobs", jobManager.Close)
+	if recorder != nil {
+		closeLogged("history", recorder.Close)
+	}
+	closeLogged("datasets", datasets.Close)
+	closeLogged("engine client", engineClient.Close)
+	closeLogged("logic client", logicClient.Close)
+	if cacheClose != nil {
+		closeLogged("result cache", cacheClose)
+	}
+	for _, rdb := range redisClients {
+		closeLogged("redis "+rdb.Options().Addr, rdb.Close)
+	}
+	closeLogged("mysql", db.Close)
+	closeLogged("tracing", func() error {
+		// the drain deadline may already be spent; give the flush its own
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(flushCtx)
+	})
+	slog.Info("shutdown complete")
+}
+
+func closeLogged(name string, close func() error) {
+	if err := close(); err != nil {
+		slog.Warn("shutdown: close failed", "component", name, "err", err)
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
 }
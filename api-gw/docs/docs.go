@@ -15,28 +15,22 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Create a session and set an HTTP-only cookie",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/cache/key": {
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Login",
+                "summary": "Delete one cache entry",
                 "parameters": [
                     {
-                        "description": "Credentials",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth.loginReq"
-                        }
+                        "type": "string",
+                        "description": "Full cache key",
+                        "name": "key",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -49,54 +43,26 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/auth/logout": {
-            "post": {
-                "description": "Invalidate the session and clear the cookie",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "Logout",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/auth/me": {
-            "get": {
-                "description": "Returns the logged-in user if any",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "Current user",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -107,38 +73,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "Creates a user with unique username; logs user in by setting the session cookie",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/cache/keys": {
+            "get": {
+                "description": "Lists live cache keys starting with the given prefix",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Register a new user",
+                "summary": "List cache keys",
                 "parameters": [
                     {
-                        "description": "New user",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth.registerReq"
-                        }
+                        "type": "string",
+                        "description": "Key prefix, e.g. engine:matmul",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Max keys to return",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -150,8 +116,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -171,28 +137,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/engine/hello": {
-            "get": {
-                "description": "Triggers the Hello RPC on the C++ Thrift EngineService",
+        "/admin/cache/lookup": {
+            "post": {
+                "description": "Derives the cache key the given route would use for body and returns the stored entry, if any",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "engine"
+                    "admin"
                 ],
-                "summary": "Call C++ EngineService Hello RPC",
+                "summary": "Look up a cache entry by request body",
                 "parameters": [
                     {
-                        "type": "string",
-                        "default": "World",
-                        "description": "Name to greet",
-                        "name": "name",
-                        "in": "query"
+                        "description": "Route and original request body",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/admin.lookupReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -200,8 +178,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -212,28 +199,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/engine/matmul": {
-            "post": {
-                "description": "Calls EngineService.MatMul with two matrices A and B",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/cache/prefix": {
+            "delete": {
+                "description": "Deletes every cache entry whose key starts with prefix (e.g. engine:matmul)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "engine"
+                    "admin"
                 ],
-                "summary": "Matrix multiply",
+                "summary": "Purge cache entries by prefix",
                 "parameters": [
                     {
-                        "description": "A and B matrices",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/engine.MatMulDTO"
-                        }
+                        "type": "string",
+                        "description": "Key prefix",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -253,6 +235,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -265,28 +256,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/engine/pi": {
-            "post": {
-                "description": "Calls EngineService.EstimatePi with given sample size",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/cache/stats": {
+            "get": {
+                "description": "Hit/miss/set/delete counters per key prefix, plus backend counters when available. size=true adds the live entry count per prefix, counted up to 10000 (\"capped\" when there are more); it scans the cache, so leave it off for frequent polling.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "engine"
+                    "admin"
                 ],
-                "summary": "Estimate π via Monte Carlo",
+                "summary": "Result cache statistics",
                 "parameters": [
                     {
-                        "description": "Pi input",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/engine.PiDTO"
-                        }
+                        "type": "boolean",
+                        "description": "Count live entries per prefix",
+                        "name": "size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -297,17 +282,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -318,9 +294,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/engine/stats": {
+        "/auth/login": {
             "post": {
-                "description": "Calls EngineService.ComputeStats on a dataset (sample variance by default)",
+                "description": "Create a session and set an HTTP-only cookie",
                 "consumes": [
                     "application/json"
                 ],
@@ -328,30 +304,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "engine"
+                    "auth"
                 ],
-                "summary": "Compute vector statistics",
+                "summary": "Login",
                 "parameters": [
                     {
-                        "description": "Stats input",
+                        "description": "Credentials",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/engine.StatsDTO"
+                            "$ref": "#/definitions/auth.loginReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -359,8 +328,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -371,16 +340,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/healthz": {
-            "get": {
-                "description": "Liveness/readiness probe endpoint",
+        "/auth/logout": {
+            "post": {
+                "description": "Invalidate the session and clear the cookie",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "auth"
                 ],
-                "summary": "Health check",
+                "summary": "Logout",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -394,16 +363,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/hello": {
+        "/auth/me": {
             "get": {
-                "description": "Basic greeting from Harmonia API Gateway",
+                "description": "Returns the logged-in user if any",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "root"
+                    "auth"
                 ],
-                "summary": "Hello endpoint",
+                "summary": "Current user",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -417,9 +386,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/logic/eval": {
+        "/auth/register": {
             "post": {
-                "description": "Evaluate a numeric expression with optional variables via LogicService.Evaluate",
+                "description": "Creates a user with unique username; logs user in by setting the session cookie",
                 "consumes": [
                     "application/json"
                 ],
@@ -427,26 +396,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "logic"
+                    "auth"
                 ],
-                "summary": "Evaluate expression",
+                "summary": "Register a new user",
                 "parameters": [
                     {
-                        "description": "Eval input",
+                        "description": "New user",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/logic.EvalDTO"
+                            "$ref": "#/definitions/auth.registerReq"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
@@ -458,6 +429,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -470,23 +450,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/logic/hello": {
+        "/datasets": {
             "get": {
-                "description": "Triggers the Hello RPC on the Python gRPC LogicService",
+                "description": "The caller's datasets, newest first; name narrows it to the versions of one dataset",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "logic"
+                    "datasets"
                 ],
-                "summary": "Call Python LogicService Hello RPC",
+                "summary": "List my datasets",
                 "parameters": [
                     {
                         "type": "string",
-                        "default": "World",
-                        "description": "Name to greet",
+                        "description": "Dataset name",
                         "name": "name",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of datasets",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -494,13 +480,1468 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                    }
+                }
+            },
+            "post": {
+                "description": "Stores a vector or matrix under a name, as multipart (field \"file\") or as the raw body. JSON takes [..], [[..], ..] or {rows, cols, data}; CSV takes one row per line (optional header; one row or column is a vector); .npy takes a 1-D or 2-D numeric array. Uploading to an existing name adds a version, unless the values equal the latest one. Reference the returned ID as \"dataset_id\" in place of \"data\" (or of a whole matrix) in engine and logic requests.",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "datasets"
+                ],
+                "summary": "Upload a dataset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dataset name (letters, digits, . _ -)",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "json | csv | npy (default: from the file name or Content-Type)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "The file, for multipart uploads",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unchanged: the latest version holds the same values",
+                        "schema": {
+                            "$ref": "#/definitions/dataset.Dataset"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dataset.Dataset"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/datasets/{id}": {
+            "get": {
+                "description": "Shape, version, content hash and size, plus the first values",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "datasets"
+                ],
+                "summary": "Describe a dataset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dataset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "datasets"
+                ],
+                "summary": "Delete a dataset version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dataset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/datasets/{id}/data": {
+            "get": {
+                "description": "The values row-major, with the shape",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "datasets"
+                ],
+                "summary": "Dataset values",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dataset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/hello": {
+            "get": {
+                "description": "Triggers the Hello RPC on the C++ Thrift EngineService",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "engine"
+                ],
+                "summary": "Call C++ EngineService Hello RPC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "World",
+                        "description": "Name to greet",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/matmul": {
+            "post": {
+                "description": "Calls EngineService.MatMul with two matrices A and B",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "engine"
+                ],
+                "summary": "Matrix multiply",
+                "parameters": [
+                    {
+                        "description": "A and B matrices",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/engine.MatMulDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (If-None-Match matched the ETag)"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/matmul:batch": {
+            "post": {
+                "description": "Runs MatMul for each pair in the array; cache hits are fetched in one lookup and misses fan out with bounded concurrency. Items come back in order with the status the single route would have used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "engine"
+                ],
+                "summary": "Matrix multiply, batched",
+                "parameters": [
+                    {
+                        "description": "Array of A/B pairs",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/engine.MatMulDTO"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/pi": {
+            "post": {
+                "description": "Calls EngineService.EstimatePi with given sample size",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "engine"
+                ],
+                "summary": "Estimate π via Monte Carlo",
+                "parameters": [
+                    {
+                        "description": "Pi input",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/engine.PiDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (If-None-Match matched the ETag)"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/pool": {
+            "get": {
+                "description": "Reports the balancing policy and, per engine endpoint, its health, outstanding calls and Thrift pool counters",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "engine"
+                ],
+                "summary": "Engine endpoints and connection pools",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/engine.BalancerStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/stats": {
+            "post": {
+                "description": "Calls EngineService.ComputeStats on a dataset (sample variance by default)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "engine"
+                ],
+                "summary": "Compute vector statistics",
+                "parameters": [
+                    {
+                        "description": "Stats input",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/engine.StatsDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (If-None-Match matched the ETag)"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/stats:batch": {
+            "post": {
+                "description": "Runs ComputeStats for each dataset in the array; cache hits are fetched in one lookup and misses fan out with bounded concurrency. Items come back in order with the status the single route would have used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "engine"
+                ],
+                "summary": "Compute vector statistics, batched",
+                "parameters": [
+                    {
+                        "description": "Array of stats inputs",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/engine.StatsDTO"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports that the process is up; does not look at dependencies (see /readyz)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/hello": {
+            "get": {
+                "description": "Basic greeting from Harmonia API Gateway",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "root"
+                ],
+                "summary": "Hello endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/history": {
+            "get": {
+                "description": "The caller's successful engine/logic calls, newest first, without request and result bodies. Pass next_cursor back as cursor for the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "List my computation history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operation, e.g. engine:matmul",
+                        "name": "op",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest time, YYYY-MM-DD or RFC 3339 (inclusive)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest time, YYYY-MM-DD (whole day) or RFC 3339 (exclusive)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes the caller's entries matching op/from/to; with no filter, all=true is required",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "Delete history entries in bulk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operation, e.g. engine:matmul",
+                        "name": "op",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest time, YYYY-MM-DD or RFC 3339 (inclusive)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest time, YYYY-MM-DD (whole day) or RFC 3339 (exclusive)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Confirm deleting the whole history",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/history/{id}": {
+            "get": {
+                "description": "The recorded request and result (or a cache key reference for large results)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "One history entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/history.Entry"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "Delete a history entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/history/{id}/rerun": {
+            "post": {
+                "description": "Sends the recorded request to its route again and returns that route's response; the new call is recorded as a new entry. Cache-Control is honoured, so \"no-cache\" forces a fresh computation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "history"
+                ],
+                "summary": "Re-run a past request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "get": {
+                "description": "The caller's jobs, newest first, without inputs or results",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "List my jobs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of jobs",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Queues an engine or logic operation and returns its job ID; poll /jobs/{id} for progress",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Submit a background job",
+                "parameters": [
+                    {
+                        "description": "Operation and its input",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/jobs.SubmitDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "description": "Status, timings and, once finished, the result or error of a job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Job status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels a queued or running job; finished jobs are left as they are (409)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Cancel a job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/events": {
+            "get": {
+                "description": "Server-Sent Events for one job: \"status\" on every transition, \"progress\" with partial results (e.g. running π estimates), \"task\" per planner task, and a final \"result\". Comment lines are heartbeats. A \"shutdown\" event means the gateway is draining: reconnect with Last-Event-ID (or last_event_id) to resume.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Stream job events (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Same as Last-Event-ID, for clients that cannot set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}/result": {
+            "get": {
+                "description": "The result body of a succeeded job, the error of a failed one (with the status the synchronous route would have used), or 409 while it is unfinished",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Job result",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/logic/channel": {
+            "get": {
+                "description": "Reports the connectivity state (IDLE/CONNECTING/READY/TRANSIENT_FAILURE/SHUTDOWN) of the shared gRPC channel",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logic"
+                ],
+                "summary": "LogicService channel state",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/logic/eval": {
+            "post": {
+                "description": "Evaluate a numeric expression with optional variables via LogicService.Evaluate",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logic"
+                ],
+                "summary": "Evaluate expression",
+                "parameters": [
+                    {
+                        "description": "Eval input",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/logic.EvalDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified (If-None-Match matched the ETag)"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/logic/eval:batch": {
+            "post": {
+                "description": "Evaluates each expression in the array; cache hits are fetched in one lookup and misses fan out with bounded concurrency. Items come back in order with the status the single route would have used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logic"
+                ],
+                "summary": "Evaluate expressions, batched",
+                "parameters": [
+                    {
+                        "description": "Array of eval inputs",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/logic.EvalDTO"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/logic/hello": {
+            "get": {
+                "description": "Triggers the Hello RPC on the Python gRPC LogicService",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "logic"
+                ],
+                "summary": "Call Python LogicService Hello RPC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "World",
+                        "description": "Name to greet",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -533,6 +1974,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/logic.PlanDTO"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -543,6 +1996,9 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "304": {
+                        "description": "Not modified (If-None-Match matched the ETag)"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -552,8 +2008,35 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -586,6 +2069,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/logic.TransformDTO"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache | no-store | max-age=N",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -596,6 +2091,9 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "304": {
+                        "description": "Not modified (If-None-Match matched the ETag)"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -605,13 +2103,130 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/pipeline": {
+            "post": {
+                "description": "Runs declarative steps, wiring earlier results into later inputs (\"wire\": {\"data\": \"square.data\"}); independent steps run concurrently and each is cached like its own route. Step failures are reported per step.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pipeline"
+                ],
+                "summary": "Run a pipeline of logic and engine steps",
+                "parameters": [
+                    {
+                        "description": "Steps",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/pipeline.PipelineDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/pipeline.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Probes MySQL, Redis, the engine and the logic service (results cached briefly). 503 when a critical dependency is down; a non-critical one only marks the gateway degraded. Why a probe failed is logged, not returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Circuit breaker state for each backend (closed / open / half-open)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Backend status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     }
                 }
@@ -619,6 +2234,26 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "admin.lookupReq": {
+            "type": "object",
+            "required": [
+                "body",
+                "route"
+            ],
+            "properties": {
+                "body": {
+                    "description": "the request body sent to that route",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "route": {
+                    "description": "e.g. \"engine:matmul\"",
+                    "type": "string"
+                }
+            }
+        },
         "auth.loginReq": {
             "type": "object",
             "properties": {
@@ -645,6 +2280,76 @@ const docTemplate = `{
                 }
             }
         },
+        "dataset.Dataset": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "cols": {
+                    "description": "the length, for a vector",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "vector | matrix",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rows": {
+                    "description": "1 for a vector",
+                    "type": "integer"
+                },
+                "sha256": {
+                    "description": "of the values as little-endian float64",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "engine.BalancerStats": {
+            "type": "object",
+            "properties": {
+                "endpoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/engine.EndpointStats"
+                    }
+                },
+                "policy": {
+                    "type": "string"
+                }
+            }
+        },
+        "engine.EndpointStats": {
+            "type": "object",
+            "properties": {
+                "addr": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "outstanding": {
+                    "type": "integer"
+                },
+                "pool": {
+                    "$ref": "#/definitions/engine.PoolStats"
+                }
+            }
+        },
         "engine.MatMulDTO": {
             "type": "object",
             "required": [
@@ -696,6 +2401,38 @@ const docTemplate = `{
                 }
             }
         },
+        "engine.PoolStats": {
+            "type": "object",
+            "properties": {
+                "dials": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "idle": {
+                    "type": "integer"
+                },
+                "in_use": {
+                    "type": "integer"
+                },
+                "max_idle": {
+                    "type": "integer"
+                },
+                "max_open": {
+                    "type": "integer"
+                },
+                "open": {
+                    "type": "integer"
+                },
+                "reuses": {
+                    "type": "integer"
+                },
+                "waits": {
+                    "type": "integer"
+                }
+            }
+        },
         "engine.StatsDTO": {
             "type": "object",
             "required": [
@@ -714,6 +2451,178 @@ const docTemplate = `{
                 }
             }
         },
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "type": "boolean"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "description": "\"up\" | \"down\"",
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "draining": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "\"ready\" | \"degraded\" | \"not_ready\"",
+                    "type": "string"
+                }
+            }
+        },
+        "history.Entry": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "op": {
+                    "description": "route name, e.g. \"engine:matmul\"",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "request": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "rerunnable": {
+                    "description": "false when the request body was over the size limit or the path was clipped",
+                    "type": "boolean"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "result_ref": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.Job": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_kind": {
+                    "description": "rpcerr kind of a failed job",
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "input": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "op": {
+                    "description": "e.g. \"engine:matmul\"",
+                    "type": "string"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/jobs.Status"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "jobs.Status": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "succeeded",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "Queued",
+                "Running",
+                "Succeeded",
+                "Failed",
+                "Cancelled"
+            ]
+        },
+        "jobs.SubmitDTO": {
+            "type": "object",
+            "required": [
+                "input",
+                "op"
+            ],
+            "properties": {
+                "input": {
+                    "description": "the body the synchronous route takes",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "op": {
+                    "description": "e.g. \"engine:matmul\", \"logic:plan\"",
+                    "type": "string"
+                },
+                "timeout_seconds": {
+                    "description": "optional; capped by the server",
+                    "type": "integer"
+                }
+            }
+        },
         "logic.EvalDTO": {
             "type": "object",
             "required": [
@@ -763,6 +2672,7 @@ const docTemplate = `{
             ],
             "properties": {
                 "data": {
+                    "description": "or \"dataset_id\" in its place (see /datasets)",
                     "type": "array",
                     "items": {
                         "type": "number"
@@ -781,6 +2691,106 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "pipeline.PipelineDTO": {
+            "type": "object",
+            "required": [
+                "steps"
+            ],
+            "properties": {
+                "steps": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/pipeline.StepDTO"
+                    }
+                }
+            }
+        },
+        "pipeline.Report": {
+            "type": "object",
+            "properties": {
+                "ok": {
+                    "type": "boolean"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/pipeline.StepResult"
+                    }
+                },
+                "took_ms": {
+                    "type": "number"
+                }
+            }
+        },
+        "pipeline.StepDTO": {
+            "type": "object",
+            "required": [
+                "id",
+                "op"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "input": {
+                    "description": "the body the synchronous route takes",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "op": {
+                    "description": "e.g. \"logic:transform\" (or \"logic.transform\")",
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "description": "optional per-step deadline",
+                    "type": "integer"
+                },
+                "wire": {
+                    "description": "Wire maps an input field path to \"\u003cstep id\u003e.\u003cresult path\u003e\", e.g.\n{\"data\": \"square.data\"} or {\"variables.x\": \"first.result\"}.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "pipeline.StepResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "start_ms": {
+                    "description": "offset from the start of the pipeline",
+                    "type": "number"
+                },
+                "status": {
+                    "description": "succeeded | failed | skipped",
+                    "type": "string"
+                },
+                "took_ms": {
+                    "type": "number"
+                }
+            }
         }
     },
     "tags": [
@@ -800,6 +2810,26 @@ const docTemplate = `{
             "description": "C++ Thrift EngineService",
             "name": "engine"
         },
+        {
+            "description": "Operator-only endpoints (cache inspection and invalidation)",
+            "name": "admin"
+        },
+        {
+            "description": "Background jobs for long-running engine and logic operations",
+            "name": "jobs"
+        },
+        {
+            "description": "Multi-step logic/engine pipelines",
+            "name": "pipeline"
+        },
+        {
+            "description": "Per-user record of past engine and logic calls",
+            "name": "history"
+        },
+        {
+            "description": "Stored vectors and matrices, referenced by ID from engine and logic requests",
+            "name": "datasets"
+        },
         {
             "description": "Liveness \u0026 readiness",
             "name": "health"
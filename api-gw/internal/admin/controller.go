@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
+)
+
+type Controller struct {
+	kvs    *cache.StatsStore
+	ld     *cache.Loader
+	routes map[string]cache.RouteSpec
+}
+
+// New builds the admin controller from the result cache, the loader that
+// encodes its entries, and the cached routes exposed by each service.
+func New(kvs *cache.StatsStore, ld *cache.Loader, routes ...map[string]cache.RouteSpec) *Controller {
+	all := make(map[string]cache.RouteSpec)
+	for _, m := range routes {
+		for route, spec := range m {
+			all[route] = spec
+		}
+	}
+	return &Controller{kvs: kvs, ld: ld, routes: all}
+}
+
+// Register wires the /admin/cache endpoints; rg must already enforce admin access.
+func Register(rg *gin.RouterGroup, c *Controller) {
+	g := rg.Group("/admin/cache")
+	g.GET("/stats", c.CacheStats)
+	g.GET("/keys", c.ListKeys)
+	g.POST("/lookup", c.Lookup)
+	g.DELETE("/key", c.DeleteKey)
+	g.DELETE("/prefix", c.DeletePrefix)
+}
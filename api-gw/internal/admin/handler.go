@@ -0,0 +1,205 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+)
+
+type routeReport struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Sets    int64 `json:"sets"`
+	Deletes int64 `json:"deletes"`
+	Errors  int64 `json:"errors"`
+	Size    *int  `json:"size,omitempty"`   // live entries, when asked for
+	Capped  bool  `json:"capped,omitempty"` // Size stopped counting at sizeCap
+}
+
+// sizeCap bounds the keys CacheStats counts per route: each count is a scan
+// of the key space.
+const sizeCap = 10_000
+
+type lookupReq struct {
+	Route string          `json:"route" binding:"required"` // e.g. "engine:matmul"
+	Body  json.RawMessage `json:"body"  binding:"required"` // the request body sent to that route
+}
+
+// CacheStats godoc
+// @Summary      Result cache statistics
+// @Description  Hit/miss/set/delete counters per key prefix, plus backend counters when available. size=true adds the live entry count per prefix, counted up to 10000 ("capped" when there are more); it scans the cache, so leave it off for frequent polling.
+// @Tags         admin
+// @Produce      json
+// @Param        size  query  bool  false  "Count live entries per prefix"
+// @Success      200   {object}  map[string]any
+// @Failure      403   {object}  map[string]string
+// @Router       /admin/cache/stats [get]
+func (c *Controller) CacheStats(ctx *gin.Context) {
+	counters := c.kvs.RouteStats()
+	routes := make(map[string]bool)
+	for r := range c.routes {
+		routes[r] = true
+	}
+	for r := range counters {
+		routes[r] = true
+	}
+
+	withSize, _ := strconv.ParseBool(ctx.Query("size"))
+	out := make(map[string]routeReport, len(routes))
+	for r := range routes {
+		rs := counters[r]
+		rep := routeReport{Hits: rs.Hits, Misses: rs.Misses, Sets: rs.Sets, Deletes: rs.Deletes, Errors: rs.Errors}
+		if !withSize {
+			out[r] = rep
+			continue
+		}
+		if keys, err := c.kvs.Keys(ctx, r+":", sizeCap+1); err == nil {
+			n := min(len(keys), sizeCap)
+			rep.Size, rep.Capped = &n, len(keys) > sizeCap
+		}
+		out[r] = rep
+	}
+
+	resp := gin.H{"routes": out}
+	if bs, ok := c.kvs.BackendStats(); ok {
+		resp["backend"] = bs
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ListKeys godoc
+// @Summary      List cache keys
+// @Description  Lists live cache keys starting with the given prefix
+// @Tags         admin
+// @Produce      json
+// @Param        prefix  query  string  true   "Key prefix, e.g. engine:matmul"
+// @Param        limit   query  int     false  "Max keys to return"  default(100)
+// @Success      200     {object}  map[string]any
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/cache/keys [get]
+func (c *Controller) ListKeys(ctx *gin.Context) {
+	prefix := ctx.Query("prefix")
+	if prefix == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required", "request_id": requestid.Get(ctx)})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit", "request_id": requestid.Get(ctx)})
+		return
+	}
+	keys, err := c.kvs.Keys(ctx, prefix, 0)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "cache scan failed: " + err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	}
+	sort.Strings(keys)
+	total := len(keys)
+	if total > limit {
+		keys = keys[:limit]
+	}
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys, "total": total})
+}
+
+// Lookup godoc
+// @Summary      Look up a cache entry by request body
+// @Description  Derives the cache key the given route would use for body and returns the stored entry, if any
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  lookupReq  true  "Route and original request body"
+// @Success      200      {object}  map[string]any
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Router       /admin/cache/lookup [post]
+func (c *Controller) Lookup(ctx *gin.Context) {
+	var req lookupReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
+		return
+	}
+	spec, ok := c.routes[req.Route]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown route: " + req.Route, "request_id": requestid.Get(ctx)})
+		return
+	}
+	key, err := spec.Key(req.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "body does not match route input: " + err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	}
+
+	reply := spec.Reply()
+	storedAt, found, err := c.ld.Peek(ctx, key, reply)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "cache entry unreadable: " + err.Error(), "key": key, "request_id": requestid.Get(ctx)})
+		return
+	}
+	if !found {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "not cached", "key": key, "request_id": requestid.Get(ctx)})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"key":       key,
+		"value":     reply,
+		"stored_at": storedAt,
+		"age":       time.Since(storedAt).Seconds(),
+	})
+}
+
+// DeleteKey godoc
+// @Summary      Delete one cache entry
+// @Tags         admin
+// @Produce      json
+// @Param        key  query  string  true  "Full cache key"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/cache/key [delete]
+func (c *Controller) DeleteKey(ctx *gin.Context) {
+	key := ctx.Query("key")
+	if key == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "key is required", "request_id": requestid.Get(ctx)})
+		return
+	}
+	if err := c.kvs.Delete(ctx, key); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "cache delete failed: " + err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": key})
+}
+
+// DeletePrefix godoc
+// @Summary      Purge cache entries by prefix
+// @Description  Deletes every cache entry whose key starts with prefix (e.g. engine:matmul)
+// @Tags         admin
+// @Produce      json
+// @Param        prefix  query  string  true  "Key prefix"
+// @Success      200     {object}  map[string]any
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/cache/prefix [delete]
+func (c *Controller) DeletePrefix(ctx *gin.Context) {
+	prefix := ctx.Query("prefix")
+	if prefix == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required", "request_id": requestid.Get(ctx)})
+		return
+	}
+	n, err := c.kvs.DeletePrefix(ctx, prefix)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "cache purge failed: " + err.Error(), "deleted": n, "request_id": requestid.Get(ctx)})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"prefix": prefix, "deleted": n})
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
 )
 
 type Controller struct {
@@ -61,34 +63,34 @@ func (a *Controller) Register(rg *gin.RouterGroup) {
 func (a *Controller) RegisterUser(c *gin.Context) {
 	var req registerReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(c)})
 		return
 	}
 
 	req.Username = strings.TrimSpace(req.Username)
 	if len(req.Username) < 3 || len(req.Username) > 64 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "username must be 3-64 chars"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username must be 3-64 chars", "request_id": requestid.Get(c)})
 		return
 	}
 	if len(req.Password) < 6 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "password must be at least 6 chars"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password must be at least 6 chars", "request_id": requestid.Get(c)})
 		return
 	}
 
 	u, err := a.users.Create(c, req.Username, req.Password)
 	if err != nil {
 		if err == ErrUserExists {
-			c.JSON(http.StatusConflict, gin.H{"error": "username already exists"})
+			c.JSON(http.StatusConflict, gin.H{"error": "username already exists", "request_id": requestid.Get(c)})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user", "request_id": requestid.Get(c)})
 		return
 	}
 
 	// Auto-login: create session and set cookie
 	token, err := a.sess.Create(u.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session", "request_id": requestid.Get(c)})
 		return
 	}
 
@@ -121,21 +123,25 @@ func (a *Controller) RegisterUser(c *gin.Context) {
 func (a *Controller) Login(c *gin.Context) {
 	var req loginReq
 	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" || req.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		logins.WithLabelValues("invalid_payload").Inc()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(c)})
 		return
 	}
 
 	u, err := a.users.GetByUsername(c, req.Username)
 	if err != nil || u == nil || !CheckPassword(u.PasswordHash, req.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		logins.WithLabelValues("invalid_credentials").Inc()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials", "request_id": requestid.Get(c)})
 		return
 	}
 
 	token, err := a.sess.Create(u.Username) // <— uses interface
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		logins.WithLabelValues("error").Inc()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session", "request_id": requestid.Get(c)})
 		return
 	}
+	logins.WithLabelValues("success").Inc()
 
 	// Set cookie (SameSite=Lax via explicit header)
 	maxAge := a.cookieMaxAge
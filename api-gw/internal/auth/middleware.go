@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
 )
 
 const CtxUserKey = "auth.user"
@@ -23,7 +25,7 @@ func RequireAuth(cookieName string, store SessionStore) gin.HandlerFunc { // <
 	return func(c *gin.Context) {
 		token, err := c.Cookie(cookieName)
 		if err != nil || token == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized", "request_id": requestid.Get(c)})
 			return
 		}
 		if user, ok := store.Get(token); ok {
@@ -31,6 +33,21 @@ func RequireAuth(cookieName string, store SessionStore) gin.HandlerFunc { // <
 			c.Next()
 			return
 		}
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized", "request_id": requestid.Get(c)})
+	}
+}
+
+// RequireAdmin must run after RequireAuth; it admits only the listed users.
+func RequireAdmin(admins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(admins))
+	for _, u := range admins {
+		allowed[u] = true
+	}
+	return func(c *gin.Context) {
+		if user := c.GetString(CtxUserKey); user != "" && allowed[user] {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden", "request_id": requestid.Get(c)})
 	}
 }
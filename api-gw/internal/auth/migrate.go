@@ -20,13 +20,26 @@ func RunMigrations(ctx context.Context, db *sql.DB) error {
 	return err
 }
 
+// devUsers are seeded on every start with their username as password.
+var devUsers = []struct{ u, p string }{
+	{"patrick", "patrick"},
+	{"admin", "admin"},
+}
+
+// IsDevUser reports whether SeedDevData creates username, with a password
+// anyone can look up here.
+func IsDevUser(username string) bool {
+	for _, s := range devUsers {
+		if s.u == username {
+			return true
+		}
+	}
+	return false
+}
+
 func SeedDevData(ctx context.Context, db *sql.DB) error {
 	// idempotent upserts (MySQL)
-	seed := []struct{ u, p string }{
-		{"patrick", "patrick"},
-		{"admin", "admin"},
-	}
-	for _, s := range seed {
+	for _, s := range devUsers {
 		hash, _ := bcrypt.GenerateFromPassword([]byte(s.p), bcrypt.DefaultCost)
 		_, err := db.ExecContext(ctx, `
 			INSERT INTO users (username, password_hash)
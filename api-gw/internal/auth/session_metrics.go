@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	sessionOps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "harmonia_session_operations_total",
+		Help: "Session store operations: create (ok/error), lookup (hit/miss), delete.",
+	}, []string{"op", "result"})
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "harmonia_auth_logins_total",
+		Help: "Login attempts by result (success, invalid_credentials, invalid_payload, error).",
+	}, []string{"result"})
+)
+
+type instrumentedStore struct{ SessionStore }
+
+// Instrumented wraps a SessionStore so its operations are counted in
+// harmonia_session_operations_total.
+func Instrumented(s SessionStore) SessionStore { return instrumentedStore{s} }
+
+func (s instrumentedStore) Create(user string) (string, error) {
+	token, err := s.SessionStore.Create(user)
+	if err != nil {
+		sessionOps.WithLabelValues("create", "error").Inc()
+	} else {
+		sessionOps.WithLabelValues("create", "ok").Inc()
+	}
+	return token, err
+}
+
+func (s instrumentedStore) Get(token string) (string, bool) {
+	user, ok := s.SessionStore.Get(token)
+	if ok {
+		sessionOps.WithLabelValues("lookup", "hit").Inc()
+	} else {
+		sessionOps.WithLabelValues("lookup", "miss").Inc()
+	}
+	return user, ok
+}
+
+func (s instrumentedStore) Delete(token string) {
+	s.SessionStore.Delete(token)
+	sessionOps.WithLabelValues("delete", "ok").Inc()
+}
@@ -0,0 +1,93 @@
+// Package batch holds what the engine and logic batch routes share: their
+// limits and the shape of a per-item response.
+package batch
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+// Suffix is appended to a single-item route to get its batch route, e.g.
+// "/stats"+Suffix serves POST /stats:batch. gin only unescapes "\:" in
+// routes when it runs its own listener, so the verb is matched as a
+// parameter and checked by Only instead.
+const Suffix = ":verb"
+
+// Only serves h for the ":batch" verb and 404s any other suffix.
+func Only(h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param("verb") != ":batch" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found", "request_id": requestid.Get(c)})
+			return
+		}
+		h(c)
+	}
+}
+
+// Config bounds a batch: how many items it may hold and how many cache
+// misses are sent to the backend at once.
+type Config struct {
+	MaxItems    int
+	Concurrency int
+}
+
+// Item is one entry of a batch response, at the index of its request item.
+// Status is what the single-item route would have answered.
+type Item struct {
+	Status int    `json:"status"`
+	Result gin.H  `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Kind   string `json:"kind,omitempty"`
+}
+
+func OK(result gin.H) Item { return Item{Status: http.StatusOK, Result: result} }
+
+// Failed classifies err like rpcerr.Respond does.
+func Failed(err error) Item {
+	k := rpcerr.KindOf(err)
+	return Item{Status: k.Status(), Error: err.Error(), Kind: k.String()}
+}
+
+// Invalid is an item the route would have refused with 400.
+func Invalid(err error) Item {
+	return Item{Status: http.StatusBadRequest, Error: err.Error(), Kind: "invalid_payload"}
+}
+
+// Bind decodes a JSON array of T and checks its size, answering 400 or 413
+// itself when it returns false.
+func Bind[T any](c *gin.Context, cfg Config, dst *[]T) bool {
+	if err := c.ShouldBindJSON(dst); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload: want a JSON array of items", "request_id": requestid.Get(c)})
+		return false
+	}
+	if len(*dst) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty batch", "request_id": requestid.Get(c)})
+		return false
+	}
+	if cfg.MaxItems > 0 && len(*dst) > cfg.MaxItems {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":      fmt.Sprintf("batch has %d items; the limit is %d", len(*dst), cfg.MaxItems),
+			"request_id": requestid.Get(c),
+		})
+		return false
+	}
+	return true
+}
+
+// Respond writes the items in request order with a summary.
+func Respond(c *gin.Context, items []Item) {
+	failed, cached := 0, 0
+	for _, it := range items {
+		if it.Status != http.StatusOK {
+			failed++
+		} else if v, ok := it.Result["cached"].(bool); ok && v {
+			cached++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "count": len(items), "failed": failed, "cached": cached})
+}
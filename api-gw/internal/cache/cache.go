@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -12,10 +13,52 @@ type Store interface {
 	Get(ctx context.Context, key string, dst any) (bool, error)
 	Set(ctx context.Context, key string, val any, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
+
+	// Keys lists live keys starting with prefix, stopping after limit of
+	// them when limit > 0; DeletePrefix removes them and returns how many
+	// were removed.
+	Keys(ctx context.Context, prefix string, limit int) ([]string, error)
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
 }
 
-func Key(prefix string, input any) string {
+// Key builds "<route>:<schema>:<sha256 of the JSON input>". schema must be
+// bumped whenever the cached reply type changes shape, so entries written by
+// an older gateway are never decoded into the new type.
+func Key(route, schema string, input any) string {
 	b, _ := json.Marshal(input)
 	sum := sha256.Sum256(b)
-	return prefix + ":" + hex.EncodeToString(sum[:])
+	return route + ":" + schema + ":" + hex.EncodeToString(sum[:])
+}
+
+// KeyFunc derives the cache key a route would use for a raw request body.
+type KeyFunc func(body []byte) (string, error)
+
+// RouteSpec describes a cached route for inspection: how to derive its key
+// from a request body and how to allocate the reply type it stores.
+type RouteSpec struct {
+	Key   KeyFunc
+	Reply func() any
+}
+
+// BodyKey adapts a typed key builder into a KeyFunc by decoding the body as T.
+func BodyKey[T any](build func(T) string) KeyFunc {
+	return func(body []byte) (string, error) {
+		var in T
+		if err := json.Unmarshal(body, &in); err != nil {
+			return "", err
+		}
+		return build(in), nil
+	}
+}
+
+// Route returns the route a key was built with (e.g. "engine:matmul").
+func Route(key string) string {
+	for range 2 { // drop ":<hash>" then ":<schema>"
+		i := strings.LastIndexByte(key, ':')
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return key
 }
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec turns cached replies into bytes and back.
+type Codec interface {
+	ID() byte // recorded in every entry; a mismatch reads as a miss
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes values with encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) ID() byte                           { return 'j' }
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// BinaryCodec uses the native wire format of the RPC types: protobuf for
+// logic (gRPC) replies and Thrift binary protocol for engine replies. Other
+// values fall back to JSON.
+type BinaryCodec struct{}
+
+func (BinaryCodec) ID() byte { return 'b' }
+
+func (BinaryCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Marshal(m)
+	case thrift.TStruct:
+		return thrift.NewTSerializer().Write(context.Background(), m)
+	}
+	return json.Marshal(v)
+}
+
+func (BinaryCodec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	case thrift.TStruct:
+		return thrift.NewTDeserializer().Read(context.Background(), m, data)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Encoding selects the codec and when payloads are gzip-compressed.
+type Encoding struct {
+	Codec         Codec
+	CompressAbove int // payloads larger than this many bytes are gzipped; <= 0 disables
+}
+
+// Entry layout written by Loader:
+//
+//	[0]    entryFormat
+//	[1]    codec ID
+//	[2]    flags (flagGzip)
+//	[3:11] stored-at, Unix nanoseconds, big endian
+//	[11:]  payload
+const (
+	entryFormat    = 1
+	entryHeaderLen = 11
+	flagGzip       = 1 << 0
+)
+
+var errEntryFormat = errors.New("cache: unrecognised entry encoding")
+
+func (enc Encoding) encode(v any, storedAt time.Time) ([]byte, error) {
+	payload, err := enc.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var flags byte
+	if enc.CompressAbove > 0 && len(payload) > enc.CompressAbove {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		payload, flags = buf.Bytes(), flagGzip
+	}
+	out := make([]byte, entryHeaderLen, entryHeaderLen+len(payload))
+	out[0], out[1], out[2] = entryFormat, enc.Codec.ID(), flags
+	binary.BigEndian.PutUint64(out[3:], uint64(storedAt.UnixNano()))
+	return append(out, payload...), nil
+}
+
+func (enc Encoding) decode(raw []byte, v any) (time.Time, error) {
+	if len(raw) < entryHeaderLen || raw[0] != entryFormat || raw[1] != enc.Codec.ID() {
+		return time.Time{}, errEntryFormat
+	}
+	storedAt := time.Unix(0, int64(binary.BigEndian.Uint64(raw[3:])))
+	payload := raw[entryHeaderLen:]
+	if raw[2]&flagGzip != 0 {
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return time.Time{}, err
+		}
+		if payload, err = io.ReadAll(zr); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return storedAt, enc.Codec.Unmarshal(payload, v)
+}
+
+// marshal and unmarshal are shared by the stores: pre-encoded []byte values
+// (what Loader writes) are kept verbatim, anything else goes through JSON.
+func marshal(val any) ([]byte, error) {
+	if b, ok := val.([]byte); ok {
+		return b, nil
+	}
+	return json.Marshal(val)
+}
+
+func unmarshal(raw []byte, dst any) error {
+	if p, ok := dst.(*[]byte); ok {
+		*p = append((*p)[:0], raw...)
+		return nil
+	}
+	return json.Unmarshal(raw, dst)
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
+	logicv1 "github.com/Patrick8894/harmonia/api-gw/gen/logic/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type plain struct {
+	Name   string    `json:"name"`
+	Values []float64 `json:"values"`
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	storedAt := time.Unix(1_700_000_000, 123456789)
+	long := plain{Name: strings.Repeat("x", 4096)}
+
+	tests := []struct {
+		name     string
+		enc      Encoding
+		in       any
+		out      func() any
+		wantGzip bool
+	}{
+		{"json", Encoding{Codec: JSONCodec{}}, &plain{Name: "a", Values: []float64{1, 2.5}}, func() any { return &plain{} }, false},
+		{"json gzipped", Encoding{Codec: JSONCodec{}, CompressAbove: 256}, &long, func() any { return &plain{} }, true},
+		{"json under threshold", Encoding{Codec: JSONCodec{}, CompressAbove: 1 << 20}, &long, func() any { return &plain{} }, false},
+		{"thrift", Encoding{Codec: BinaryCodec{}}, &eng.VectorStatsReply{Count: 3, Sum: 6, Mean: 2, Min: 1, Max: 3}, func() any { return &eng.VectorStatsReply{} }, false},
+		{"protobuf", Encoding{Codec: BinaryCodec{}}, &logicv1.EvalReply{Result: 42}, func() any { return &logicv1.EvalReply{} }, false},
+		{"protobuf gzipped", Encoding{Codec: BinaryCodec{}, CompressAbove: 16}, &logicv1.EvalReply{Error: strings.Repeat("e", 512)}, func() any { return &logicv1.EvalReply{} }, true},
+		{"binary falls back to json", Encoding{Codec: BinaryCodec{}}, &plain{Name: "b"}, func() any { return &plain{} }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := tt.enc.encode(tt.in, storedAt)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if raw[0] != entryFormat || raw[1] != tt.enc.Codec.ID() {
+				t.Fatalf("header = %v, want format %d codec %q", raw[:2], entryFormat, tt.enc.Codec.ID())
+			}
+			if gz := raw[2]&flagGzip != 0; gz != tt.wantGzip {
+				t.Fatalf("gzip flag = %v, want %v", gz, tt.wantGzip)
+			}
+
+			out := tt.out()
+			at, err := tt.enc.decode(raw, out)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !at.Equal(storedAt) {
+				t.Errorf("stored at = %v, want %v", at, storedAt)
+			}
+			if m, ok := tt.in.(proto.Message); ok {
+				if !proto.Equal(m, out.(proto.Message)) {
+					t.Errorf("got %v, want %v", out, tt.in)
+				}
+			} else if !reflect.DeepEqual(out, tt.in) {
+				t.Errorf("got %+v, want %+v", out, tt.in)
+			}
+		})
+	}
+}
+
+func TestEncodingDecodeRejects(t *testing.T) {
+	enc := Encoding{Codec: JSONCodec{}}
+	good, err := enc.encode(plain{Name: "a"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gzipped, err := Encoding{Codec: JSONCodec{}, CompressAbove: 1}.encode(plain{Name: "a"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(b []byte, i int, v byte) []byte {
+		b = append([]byte(nil), b...)
+		b[i] = v
+		return b
+	}
+
+	tests := []struct {
+		name       string
+		raw        []byte
+		wantFormat bool // errEntryFormat, which callers treat as a miss
+	}{
+		{"empty", nil, true},
+		{"short header", good[:entryHeaderLen-1], true},
+		{"unknown format", with(good, 0, entryFormat+1), true},
+		{"other codec", with(good, 1, BinaryCodec{}.ID()), true},
+		{"corrupt gzip", append(gzipped[:entryHeaderLen:entryHeaderLen], "not gzip"...), false},
+		{"corrupt payload", append(good[:entryHeaderLen:entryHeaderLen], "{"...), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out plain
+			_, err := enc.decode(tt.raw, &out)
+			if err == nil {
+				t.Fatal("decode succeeded")
+			}
+			if got := errors.Is(err, errEntryFormat); got != tt.wantFormat {
+				t.Errorf("err = %v; errEntryFormat %v, want %v", err, got, tt.wantFormat)
+			}
+		})
+	}
+}
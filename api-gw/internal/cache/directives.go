@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Directives are per-request overrides of the cache behaviour, typically
+// parsed from an HTTP Cache-Control header.
+type Directives struct {
+	NoCache bool          // skip the lookup but store the fresh result
+	NoStore bool          // skip the lookup and don't store the result
+	MaxAge  time.Duration // only accept entries at most this old
+	HasMax  bool          // MaxAge was given (max-age=0 is meaningful)
+}
+
+type directivesKey struct{}
+
+func WithDirectives(ctx context.Context, d Directives) context.Context {
+	return context.WithValue(ctx, directivesKey{}, d)
+}
+
+func directivesFrom(ctx context.Context) Directives {
+	d, _ := ctx.Value(directivesKey{}).(Directives)
+	return d
+}
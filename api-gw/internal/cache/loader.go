@@ -0,0 +1,275 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+var tracer = otel.Tracer("github.com/Patrick8894/harmonia/api-gw/internal/cache")
+
+// Meta describes where a result handed out by Load came from.
+type Meta struct {
+	Key      string
+	Cached   bool          // served from the store
+	Stale    bool          // served past its TTL while a refresh runs
+	Shared   bool          // produced by an RPC that served several concurrent callers
+	Stored   bool          // the result is (now) in the store
+	Age      time.Duration // time since the entry was stored
+	TTL      time.Duration // freshness lifetime of the entry
+	StoredAt time.Time
+}
+
+// failure is implemented by replies that can carry a deterministic domain
+// error in their body (the logic service's error field). Such replies are
+// cached under the policy's Negative TTL.
+type failure interface{ GetError() string }
+
+func isFailure(v any) bool {
+	f, ok := v.(failure)
+	return ok && f.GetError() != ""
+}
+
+// lifetime returns how long v counts as fresh and how long after that it may
+// still be served stale.
+func (p Policy) lifetime(v any) (fresh, stale time.Duration) {
+	if isFailure(v) {
+		return p.Negative, 0
+	}
+	return p.TTL, p.Stale
+}
+
+// result is what a refresh hands to every waiter of a flight.
+type result[T any] struct {
+	value    *T
+	storedAt time.Time
+}
+
+// Loader fronts a Store with per-route TTL/stale policies and coalesces
+// concurrent misses for the same key so that only one backend call is in
+// flight per key. Entries are written as bytes in the configured Encoding,
+// stamped with when they were produced so freshness can be judged
+// independently of the store's own expiry.
+type Loader struct {
+	kvs    Store
+	pol    Policies
+	enc    Encoding
+	flight singleflight.Group
+}
+
+func NewLoader(kvs Store, pol Policies, enc Encoding) *Loader {
+	if enc.Codec == nil {
+		enc.Codec = BinaryCodec{}
+	}
+	return &Loader{kvs: kvs, pol: pol, enc: enc}
+}
+
+// Peek decodes the entry stored under key into dst without touching
+// freshness or triggering a refresh.
+func (l *Loader) Peek(ctx context.Context, key string, dst any) (time.Time, bool, error) {
+	var raw []byte
+	ok, err := l.kvs.Get(ctx, key, &raw)
+	if err != nil || !ok {
+		return time.Time{}, false, err
+	}
+	storedAt, err := l.enc.decode(raw, dst)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return storedAt, true, nil
+}
+
+func (l *Loader) lookup(ctx context.Context, key string, dst any) (time.Time, bool) {
+	ctx, span := tracer.Start(ctx, "cache.lookup", trace.WithAttributes(attribute.String("cache.route", Route(key))))
+	defer span.End()
+	storedAt, ok, err := l.Peek(ctx, key, dst)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", ok && err == nil))
+	return storedAt, ok && err == nil
+}
+
+// Load returns the cached value for key, or runs fn once for all concurrent
+// callers asking for the same key and stores its result. Transport errors
+// from fn are never stored; replies carrying a domain error are stored for
+// the policy's Negative TTL. Entries past their
+// TTL but inside the stale window are returned immediately and refreshed in
+// the background. fn runs detached from the caller's cancellation (but keeps
+// its deadline), so a caller that goes away does not fail the other waiters;
+// it just stops waiting. Directives attached to ctx can bypass the lookup or
+// the store, or bound the acceptable age.
+func Load[T any](ctx context.Context, l *Loader, key string, fn func(context.Context) (*T, error)) (*T, Meta, error) {
+	pol := l.pol.For(Route(key))
+	d := directivesFrom(ctx)
+
+	if d.NoStore {
+		v, err := fn(ctx)
+		if err != nil {
+			return nil, Meta{}, err
+		}
+		fresh, _ := pol.lifetime(v)
+		return v, Meta{Key: key, TTL: fresh, StoredAt: time.Now()}, nil
+	}
+
+	if !d.NoCache {
+		cached := new(T)
+		if storedAt, ok := l.lookup(ctx, key, cached); ok {
+			age := time.Since(storedAt)
+			fresh, stale := pol.lifetime(cached)
+			meta := Meta{Key: key, Cached: true, Stored: true, Age: age, TTL: fresh, StoredAt: storedAt}
+			switch {
+			case d.HasMax && age > d.MaxAge:
+				// too old for this caller; fall through to a fresh call
+			case age < fresh:
+				return cached, meta, nil
+			case age < fresh+stale:
+				l.flight.DoChan(key, refresh(ctx, l, key, pol, fn))
+				meta.Stale = true
+				return cached, meta, nil
+			}
+		}
+	}
+
+	return fetch(ctx, l, key, pol, fn)
+}
+
+// fetch runs fn through the flight group and waits for its result.
+func fetch[T any](ctx context.Context, l *Loader, key string, pol Policy, fn func(context.Context) (*T, error)) (*T, Meta, error) {
+	ch := l.flight.DoChan(key, refresh(ctx, l, key, pol, fn))
+	select {
+	case <-ctx.Done():
+		return nil, Meta{}, ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, Meta{}, r.Err
+		}
+		res := r.Val.(result[T])
+		fresh, _ := pol.lifetime(res.value)
+		return res.value, Meta{Key: key, Shared: r.Shared, Stored: fresh > 0, TTL: fresh, StoredAt: res.storedAt}, nil
+	}
+}
+
+// Item is one result of LoadMany.
+type Item[T any] struct {
+	Value *T
+	Meta  Meta
+	Err   error
+}
+
+// LoadMany is Load for a batch: hits for all keys are fetched in one store
+// round trip, and misses (and entries too old for the caller) are loaded
+// with fn, at most limit at a time. fn gets the index of the key it is
+// loading. Results are in key order; a failure affects only its item.
+func LoadMany[T any](ctx context.Context, l *Loader, keys []string, limit int, fn func(ctx context.Context, i int) (*T, error)) []Item[T] {
+	items := make([]Item[T], len(keys))
+	d := directivesFrom(ctx)
+	load := make([]bool, len(keys))
+	for i := range keys {
+		load[i] = true
+	}
+
+	if !d.NoCache && !d.NoStore && len(keys) > 0 {
+		ctx, span := tracer.Start(ctx, "cache.lookup_many", trace.WithAttributes(attribute.Int("cache.keys", len(keys))))
+		raws, err := GetMulti(ctx, l.kvs, keys)
+		if err != nil {
+			span.RecordError(err)
+		}
+		hits := 0
+		for i, raw := range raws {
+			if raw == nil {
+				continue
+			}
+			cached := new(T)
+			storedAt, err := l.enc.decode(raw, cached)
+			if err != nil {
+				continue
+			}
+			pol := l.pol.For(Route(keys[i]))
+			age := time.Since(storedAt)
+			fresh, stale := pol.lifetime(cached)
+			meta := Meta{Key: keys[i], Cached: true, Stored: true, Age: age, TTL: fresh, StoredAt: storedAt}
+			switch {
+			case d.HasMax && age > d.MaxAge:
+				continue
+			case age < fresh:
+			case age < fresh+stale:
+				l.flight.DoChan(keys[i], refresh(ctx, l, keys[i], pol, func(ctx context.Context) (*T, error) { return fn(ctx, i) }))
+				meta.Stale = true
+			default:
+				continue
+			}
+			items[i], load[i] = Item[T]{Value: cached, Meta: meta}, false
+			hits++
+		}
+		span.SetAttributes(attribute.Int("cache.hits", hits))
+		span.End()
+	}
+
+	sem := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		if !load[i] {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				items[i].Err = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			call := func(ctx context.Context) (*T, error) { return fn(ctx, i) }
+			var it Item[T]
+			if d.NoStore {
+				it.Value, it.Meta, it.Err = Load(ctx, l, key, call)
+			} else {
+				it.Value, it.Meta, it.Err = fetch(ctx, l, key, l.pol.For(Route(key)), call)
+			}
+			items[i] = it
+		}()
+	}
+	wg.Wait()
+	return items
+}
+
+func refresh[T any](ctx context.Context, l *Loader, key string, pol Policy, fn func(context.Context) (*T, error)) func() (any, error) {
+	return func() (any, error) {
+		rctx, cancel := detach(ctx)
+		defer cancel()
+		v, err := fn(rctx)
+		if err != nil {
+			return nil, err
+		}
+		res := result[T]{value: v, storedAt: time.Now()}
+		if fresh, stale := pol.lifetime(v); fresh > 0 {
+			if raw, err := l.enc.encode(v, res.storedAt); err == nil {
+				sctx, span := tracer.Start(rctx, "cache.store", trace.WithAttributes(
+					attribute.String("cache.route", Route(key)),
+					attribute.Int("cache.bytes", len(raw)),
+				))
+				if err := l.kvs.Set(sctx, key, raw, fresh+stale); err != nil {
+					span.RecordError(err)
+				}
+				span.End()
+			}
+		}
+		return res, nil
+	}
+}
+
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	base := context.WithoutCancel(ctx)
+	if dl, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(base, dl)
+	}
+	return context.WithCancel(base)
+}
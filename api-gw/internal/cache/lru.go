@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are cumulative counters plus the current size of a bounded store.
+type Stats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Sets      int64 `json:"sets"`
+	Evictions int64 `json:"evictions"` // dropped to stay under the size limits
+	Expired   int64 `json:"expired"`   // dropped because their TTL ran out
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+}
+
+type lruEntry struct {
+	key    string
+	raw    []byte
+	expiry time.Time
+}
+
+// LRUStore is an in-memory Store bounded by entry count and by total
+// serialized size. The least recently used entries are evicted first, and a
+// janitor goroutine purges expired entries every sweep interval.
+type LRUStore struct {
+	maxEntries int
+	maxBytes   int64
+
+	mu    sync.Mutex
+	ll    *list.List // front = most recently used
+	items map[string]*list.Element
+	bytes int64
+
+	hits, misses, sets, evictions, expired atomic.Int64
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewLRUStore creates a bounded store; a limit <= 0 disables that bound and
+// a sweep <= 0 disables the janitor.
+func NewLRUStore(maxEntries int, maxBytes int64, sweep time.Duration) *LRUStore {
+	s := &LRUStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		stop:       make(chan struct{}),
+	}
+	if sweep > 0 {
+		go s.janitor(sweep)
+	}
+	return s
+}
+
+func (s *LRUStore) Get(_ context.Context, key string, dst any) (bool, error) {
+	s.mu.Lock()
+	el, ok := s.items[key]
+	if !ok {
+		s.mu.Unlock()
+		s.misses.Add(1)
+		return false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expiry) {
+		s.removeLocked(el)
+		s.mu.Unlock()
+		s.expired.Add(1)
+		s.misses.Add(1)
+		return false, nil
+	}
+	s.ll.MoveToFront(el)
+	raw := e.raw
+	s.mu.Unlock()
+
+	if unmarshal(raw, dst) != nil {
+		s.misses.Add(1)
+		return false, nil
+	}
+	s.hits.Add(1)
+	return true, nil
+}
+
+func (s *LRUStore) Set(_ context.Context, key string, val any, ttl time.Duration) error {
+	raw, err := marshal(val)
+	if err != nil {
+		return err
+	}
+	s.setRaw(key, raw, ttl)
+	return nil
+}
+
+func (s *LRUStore) setRaw(key string, raw []byte, ttl time.Duration) {
+	// A single value larger than the whole budget would only evict everything
+	// else and then itself; don't bother storing it.
+	if s.maxBytes > 0 && int64(len(raw)) > s.maxBytes {
+		return
+	}
+	s.sets.Add(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.removeLocked(el)
+	}
+	el := s.ll.PushFront(&lruEntry{key: key, raw: raw, expiry: time.Now().Add(ttl)})
+	s.items[key] = el
+	s.bytes += int64(len(raw))
+
+	for (s.maxEntries > 0 && s.ll.Len() > s.maxEntries) || (s.maxBytes > 0 && s.bytes > s.maxBytes) {
+		s.removeLocked(s.ll.Back())
+		s.evictions.Add(1)
+	}
+}
+
+func (s *LRUStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	if el, ok := s.items[key]; ok {
+		s.removeLocked(el)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *LRUStore) Keys(_ context.Context, prefix string, limit int) ([]string, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k, el := range s.items {
+		if strings.HasPrefix(k, prefix) && now.Before(el.Value.(*lruEntry).expiry) {
+			keys = append(keys, k)
+			if len(keys) == limit {
+				break
+			}
+		}
+	}
+	return keys, nil
+}
+
+func (s *LRUStore) DeletePrefix(_ context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for k, el := range s.items {
+		if strings.HasPrefix(k, prefix) {
+			s.removeLocked(el)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *LRUStore) Stats() Stats {
+	s.mu.Lock()
+	n, b := s.ll.Len(), s.bytes
+	s.mu.Unlock()
+	return Stats{
+		Hits:      s.hits.Load(),
+		Misses:    s.misses.Load(),
+		Sets:      s.sets.Load(),
+		Evictions: s.evictions.Load(),
+		Expired:   s.expired.Load(),
+		Entries:   n,
+		Bytes:     b,
+	}
+}
+
+// Close stops the janitor.
+func (s *LRUStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *LRUStore) removeLocked(el *list.Element) {
+	e := s.ll.Remove(el).(*lruEntry)
+	delete(s.items, e.key)
+	s.bytes -= int64(len(e.raw))
+}
+
+func (s *LRUStore) janitor(every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			s.sweep()
+		}
+	}
+}
+
+func (s *LRUStore) sweep() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for el := s.ll.Back(); el != nil; {
+		prev := el.Prev()
+		if now.After(el.Value.(*lruEntry).expiry) {
+			s.removeLocked(el)
+			s.expired.Add(1)
+		}
+		el = prev
+	}
+}
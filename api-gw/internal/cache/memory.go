@@ -2,7 +2,7 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 )
@@ -31,11 +31,11 @@ func (m *MemoryStore) Get(_ context.Context, key string, dst any) (bool, error)
 		}
 		return false, nil
 	}
-	return json.Unmarshal(e.raw, dst) == nil, nil
+	return unmarshal(e.raw, dst) == nil, nil
 }
 
 func (m *MemoryStore) Set(_ context.Context, key string, val any, ttl time.Duration) error {
-	raw, err := json.Marshal(val)
+	raw, err := marshal(val)
 	if err != nil {
 		return err
 	}
@@ -51,3 +51,32 @@ func (m *MemoryStore) Delete(_ context.Context, key string) error {
 	m.mu.Unlock()
 	return nil
 }
+
+func (m *MemoryStore) Keys(_ context.Context, prefix string, limit int) ([]string, error) {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var keys []string
+	for k, e := range m.data {
+		if strings.HasPrefix(k, prefix) && now.Before(e.expiry) {
+			keys = append(keys, k)
+			if len(keys) == limit {
+				break
+			}
+		}
+	}
+	return keys, nil
+}
+
+func (m *MemoryStore) DeletePrefix(_ context.Context, prefix string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.data, k)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,99 @@
+package cache
+
+import "context"
+
+// MultiGetter is implemented by stores that can fetch many entries in one
+// round trip.
+type MultiGetter interface {
+	// GetMulti returns the raw entry for each key, nil for misses.
+	GetMulti(ctx context.Context, keys []string) ([][]byte, error)
+}
+
+// GetMulti fetches raw entries for keys, in one round trip when the store
+// supports it and one Get per key otherwise.
+func GetMulti(ctx context.Context, s Store, keys []string) ([][]byte, error) {
+	if mg, ok := s.(MultiGetter); ok {
+		return mg.GetMulti(ctx, keys)
+	}
+	out := make([][]byte, len(keys))
+	for i, k := range keys {
+		var raw []byte
+		ok, err := s.Get(ctx, k, &raw)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out[i] = raw
+		}
+	}
+	return out, nil
+}
+
+func (r *RedisStore) GetMulti(ctx context.Context, keys []string) ([][]byte, error) {
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = r.full(k)
+	}
+	vals, err := r.rdb.MGet(ctx, full...).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, len(keys))
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			out[i] = []byte(s)
+		}
+	}
+	return out, nil
+}
+
+// GetMulti serves what it can from L1 and fetches the rest from Redis with
+// a single MGET, filling L1 on the way.
+func (t *TieredStore) GetMulti(ctx context.Context, keys []string) ([][]byte, error) {
+	out := make([][]byte, len(keys))
+	var (
+		missKeys []string
+		missIdx  []int
+	)
+	for i, k := range keys {
+		var raw []byte
+		if ok, _ := t.l1.Get(ctx, k, &raw); ok {
+			out[i] = raw
+			continue
+		}
+		missKeys = append(missKeys, k)
+		missIdx = append(missIdx, i)
+	}
+	if len(missKeys) == 0 {
+		return out, nil
+	}
+	raws, err := t.l2.GetMulti(ctx, missKeys)
+	if err != nil {
+		return nil, err
+	}
+	for j, raw := range raws {
+		if raw != nil {
+			out[missIdx[j]] = raw
+			t.l1.setRaw(missKeys[j], raw, t.capTTL(0))
+		}
+	}
+	return out, nil
+}
+
+// GetMulti counts a hit or miss per key, like Get.
+func (s *StatsStore) GetMulti(ctx context.Context, keys []string) ([][]byte, error) {
+	raws, err := GetMulti(ctx, s.Store, keys)
+	for i, k := range keys {
+		c := s.counters(k)
+		switch {
+		case err != nil:
+			c.errors.Add(1)
+			c.misses.Add(1)
+		case raws[i] != nil:
+			c.hits.Add(1)
+		default:
+			c.misses.Add(1)
+		}
+	}
+	return raws, err
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Policy controls how long an entry is served as fresh and, after that, for
+// how long it may still be served while a background refresh runs. Replies
+// that carry a domain error are kept for Negative instead, with no stale
+// window.
+type Policy struct {
+	TTL      time.Duration
+	Stale    time.Duration
+	Negative time.Duration
+}
+
+// Policies maps a route (the key prefix, e.g. "engine:matmul") to its policy.
+type Policies struct {
+	Default Policy
+	Routes  map[string]Policy
+}
+
+func (p Policies) For(route string) Policy {
+	if pol, ok := p.Routes[route]; ok {
+		return pol
+	}
+	return p.Default
+}
+
+// ParsePolicies reads a comma-separated list of route=ttl[+stale] overrides,
+// for example "engine:matmul=5m+1h,logic:plan=30s". Durations use
+// time.ParseDuration syntax.
+func ParsePolicies(spec string, def Policy) (Policies, error) {
+	p := Policies{Default: def, Routes: map[string]Policy{}}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		route, val, ok := strings.Cut(item, "=")
+		if !ok {
+			return p, fmt.Errorf("cache policy %q: want route=ttl[+stale]", item)
+		}
+		ttlStr, staleStr, hasStale := strings.Cut(val, "+")
+		ttl, err := time.ParseDuration(strings.TrimSpace(ttlStr))
+		if err != nil {
+			return p, fmt.Errorf("cache policy %q: %w", item, err)
+		}
+		pol := Policy{TTL: ttl, Stale: def.Stale, Negative: def.Negative}
+		if hasStale {
+			if pol.Stale, err = time.ParseDuration(strings.TrimSpace(staleStr)); err != nil {
+				return p, fmt.Errorf("cache policy %q: %w", item, err)
+			}
+		}
+		p.Routes[strings.TrimSpace(route)] = pol
+	}
+	return p, nil
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePolicies(t *testing.T) {
+	def := Policy{TTL: time.Minute, Stale: 10 * time.Minute, Negative: 30 * time.Second}
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]Policy
+		wantErr bool
+	}{
+		{"empty", "", map[string]Policy{}, false},
+		{"ttl only keeps default stale", "engine:matmul=5m", map[string]Policy{
+			"engine:matmul": {TTL: 5 * time.Minute, Stale: 10 * time.Minute, Negative: 30 * time.Second},
+		}, false},
+		{"ttl and stale", "engine:matmul=5m+1h,logic:plan=30s", map[string]Policy{
+			"engine:matmul": {TTL: 5 * time.Minute, Stale: time.Hour, Negative: 30 * time.Second},
+			"logic:plan":    {TTL: 30 * time.Second, Stale: 10 * time.Minute, Negative: 30 * time.Second},
+		}, false},
+		{"spaces and empty items", " engine:pi = 1s + 2s , ,", map[string]Policy{
+			"engine:pi": {TTL: time.Second, Stale: 2 * time.Second, Negative: 30 * time.Second},
+		}, false},
+		{"zero stale", "logic:eval=1m+0s", map[string]Policy{
+			"logic:eval": {TTL: time.Minute, Negative: 30 * time.Second},
+		}, false},
+		{"missing =", "engine:matmul", nil, true},
+		{"bad ttl", "engine:matmul=five", nil, true},
+		{"bad stale", "engine:matmul=5m+later", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicies(tt.spec, def)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Default != def {
+				t.Errorf("default = %+v, want %+v", got.Default, def)
+			}
+			if !reflect.DeepEqual(got.Routes, tt.want) {
+				t.Errorf("routes = %+v, want %+v", got.Routes, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoliciesFor(t *testing.T) {
+	def := Policy{TTL: time.Minute}
+	p, err := ParsePolicies("engine:matmul=5m", def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.For("engine:matmul").TTL; got != 5*time.Minute {
+		t.Errorf("engine:matmul TTL = %v, want 5m", got)
+	}
+	if got := p.For("engine:stats"); got != def {
+		t.Errorf("engine:stats = %+v, want the default", got)
+	}
+}
@@ -2,7 +2,7 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -29,11 +29,11 @@ func (r *RedisStore) Get(ctx context.Context, key string, dst any) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	return json.Unmarshal([]byte(s), dst) == nil, nil
+	return unmarshal([]byte(s), dst) == nil, nil
 }
 
 func (r *RedisStore) Set(ctx context.Context, key string, val any, ttl time.Duration) error {
-	b, err := json.Marshal(val)
+	b, err := marshal(val)
 	if err != nil {
 		return err
 	}
@@ -43,3 +43,76 @@ func (r *RedisStore) Set(ctx context.Context, key string, val any, ttl time.Dura
 func (r *RedisStore) Delete(ctx context.Context, key string) error {
 	return r.rdb.Del(ctx, r.full(key)).Err()
 }
+
+// scanBatch bounds both the SCAN COUNT hint and the size of each UNLINK.
+const scanBatch = 500
+
+func (r *RedisStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	var keys []string
+	iter := r.rdb.Scan(ctx, 0, r.full(globEscape(prefix))+"*", scanBatch).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), r.keyNS))
+		if len(keys) == limit {
+			break
+		}
+	}
+	return keys, iter.Err()
+}
+
+func (r *RedisStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	n := 0
+	batch := make([]string, 0, scanBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		removed, err := r.rdb.Unlink(ctx, batch...).Result()
+		n += int(removed)
+		batch = batch[:0]
+		return err
+	}
+	iter := r.rdb.Scan(ctx, 0, r.full(globEscape(prefix))+"*", scanBatch).Iterator()
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanBatch {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, err
+	}
+	return n, flush()
+}
+
+// globEscape quotes the characters SCAN MATCH treats as wildcards.
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// getRaw returns the stored bytes together with the key's remaining TTL.
+func (r *RedisStore) getRaw(ctx context.Context, key string) ([]byte, time.Duration, bool, error) {
+	pipe := r.rdb.Pipeline()
+	get := pipe.Get(ctx, r.full(key))
+	ttl := pipe.PTTL(ctx, r.full(key))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, 0, false, err
+	}
+	b, err := get.Bytes()
+	if err == redis.Nil {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return b, ttl.Val(), true, nil
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RouteStats are the counters StatsStore keeps for one route prefix.
+type RouteStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Sets    int64 `json:"sets"`
+	Deletes int64 `json:"deletes"`
+	Errors  int64 `json:"errors"`
+}
+
+type routeCounters struct {
+	hits, misses, sets, deletes, errors atomic.Int64
+}
+
+// StatsStore wraps a Store and counts operations per route (see Route).
+type StatsStore struct {
+	Store
+
+	mu     sync.RWMutex
+	routes map[string]*routeCounters
+}
+
+func NewStatsStore(inner Store) *StatsStore {
+	return &StatsStore{Store: inner, routes: make(map[string]*routeCounters)}
+}
+
+func (s *StatsStore) counters(key string) *routeCounters {
+	route := Route(key)
+	s.mu.RLock()
+	c, ok := s.routes[route]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok = s.routes[route]; !ok {
+		c = &routeCounters{}
+		s.routes[route] = c
+	}
+	return c
+}
+
+func (s *StatsStore) Get(ctx context.Context, key string, dst any) (bool, error) {
+	ok, err := s.Store.Get(ctx, key, dst)
+	c := s.counters(key)
+	switch {
+	case err != nil:
+		c.errors.Add(1)
+		c.misses.Add(1)
+	case ok:
+		c.hits.Add(1)
+	default:
+		c.misses.Add(1)
+	}
+	return ok, err
+}
+
+func (s *StatsStore) Set(ctx context.Context, key string, val any, ttl time.Duration) error {
+	err := s.Store.Set(ctx, key, val, ttl)
+	c := s.counters(key)
+	if err != nil {
+		c.errors.Add(1)
+	} else {
+		c.sets.Add(1)
+	}
+	return err
+}
+
+func (s *StatsStore) Delete(ctx context.Context, key string) error {
+	err := s.Store.Delete(ctx, key)
+	if err != nil {
+		s.counters(key).errors.Add(1)
+	} else {
+		s.counters(key).deletes.Add(1)
+	}
+	return err
+}
+
+// RouteStats returns a snapshot of the counters keyed by route.
+func (s *StatsStore) RouteStats() map[string]RouteStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]RouteStats, len(s.routes))
+	for route, c := range s.routes {
+		out[route] = RouteStats{
+			Hits:    c.hits.Load(),
+			Misses:  c.misses.Load(),
+			Sets:    c.sets.Load(),
+			Deletes: c.deletes.Load(),
+			Errors:  c.errors.Load(),
+		}
+	}
+	return out
+}
+
+// BackendStats returns the wrapped store's own counters when it keeps any
+// (LRUStore, TieredStore).
+func (s *StatsStore) BackendStats() (Stats, bool) {
+	if bs, ok := s.Store.(interface{ Stats() Stats }); ok {
+		return bs.Stats(), true
+	}
+	return Stats{}, false
+}
+
+// ExportMetrics publishes the per-route counters as
+// harmonia_cache_operations_total{prefix,op}. Call it once per process.
+func (s *StatsStore) ExportMetrics() {
+	prometheus.MustRegister(statsCollector{s})
+}
+
+var cacheOpsDesc = prometheus.NewDesc("harmonia_cache_operations_total",
+	"Result cache operations by key prefix (route) and outcome.", []string{"prefix", "op"}, nil)
+
+// statsCollector reads the StatsStore counters at scrape time.
+type statsCollector struct{ s *StatsStore }
+
+func (c statsCollector) Describe(ch chan<- *prometheus.Desc) { ch <- cacheOpsDesc }
+
+func (c statsCollector) Collect(ch chan<- prometheus.Metric) {
+	for route, st := range c.s.RouteStats() {
+		for op, n := range map[string]int64{"hit": st.Hits, "miss": st.Misses, "set": st.Sets, "delete": st.Deletes, "error": st.Errors} {
+			ch <- prometheus.MustNewConstMetric(cacheOpsDesc, prometheus.CounterValue, float64(n), route, op)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TieredStore keeps a small per-process LRU (L1) in front of Redis (L2).
+// Writes and deletes are published on a Redis channel so other gateway
+// replicas drop their L1 copy of the key.
+type TieredStore struct {
+	l1      *LRUStore
+	l2      *RedisStore
+	rdb     *redis.Client
+	channel string
+	origin  string        // identifies our own invalidation messages
+	l1TTL   time.Duration // upper bound on how long L1 may serve a key
+
+	sub    *redis.PubSub
+	cancel context.CancelFunc
+}
+
+func NewTieredStore(l1 *LRUStore, l2 *RedisStore, rdb *redis.Client, channel string, l1TTL time.Duration) *TieredStore {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &TieredStore{
+		l1:      l1,
+		l2:      l2,
+		rdb:     rdb,
+		channel: channel,
+		origin:  hex.EncodeToString(b),
+		l1TTL:   l1TTL,
+		sub:     rdb.Subscribe(ctx, channel),
+		cancel:  cancel,
+	}
+	go t.listen()
+	return t
+}
+
+func (t *TieredStore) Get(ctx context.Context, key string, dst any) (bool, error) {
+	if ok, _ := t.l1.Get(ctx, key, dst); ok {
+		return true, nil
+	}
+	raw, ttl, ok, err := t.l2.getRaw(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if unmarshal(raw, dst) != nil {
+		return false, nil
+	}
+	t.l1.setRaw(key, raw, t.capTTL(ttl))
+	return true, nil
+}
+
+func (t *TieredStore) Set(ctx context.Context, key string, val any, ttl time.Duration) error {
+	if err := t.l2.Set(ctx, key, val, ttl); err != nil {
+		return err
+	}
+	t.publish(ctx, "k", key)
+	return t.l1.Set(ctx, key, val, t.capTTL(ttl))
+}
+
+func (t *TieredStore) Delete(ctx context.Context, key string) error {
+	_ = t.l1.Delete(ctx, key)
+	err := t.l2.Delete(ctx, key)
+	t.publish(ctx, "k", key)
+	return err
+}
+
+func (t *TieredStore) Keys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	return t.l2.Keys(ctx, prefix, limit)
+}
+
+func (t *TieredStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	_, _ = t.l1.DeletePrefix(ctx, prefix)
+	n, err := t.l2.DeletePrefix(ctx, prefix)
+	t.publish(ctx, "p", prefix)
+	return n, err
+}
+
+// Stats reports the L1 counters.
+func (t *TieredStore) Stats() Stats { return t.l1.Stats() }
+
+// Close stops the invalidation listener and the L1 janitor.
+func (t *TieredStore) Close() error {
+	t.cancel()
+	err := t.sub.Close()
+	_ = t.l1.Close()
+	return err
+}
+
+func (t *TieredStore) capTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || (t.l1TTL > 0 && ttl > t.l1TTL) {
+		return t.l1TTL
+	}
+	return ttl
+}
+
+// publish announces an invalidation as "origin|kind|target", where kind is
+// "k" for a single key and "p" for a key prefix.
+func (t *TieredStore) publish(ctx context.Context, kind, target string) {
+	if err := t.rdb.Publish(ctx, t.channel, t.origin+"|"+kind+"|"+target).Err(); err != nil {
+		slog.Warn("cache: publish invalidation failed", "target", target, "err", err)
+	}
+}
+
+func (t *TieredStore) listen() {
+	for msg := range t.sub.Channel() {
+		parts := strings.SplitN(msg.Payload, "|", 3)
+		if len(parts) != 3 || parts[0] == t.origin {
+			continue
+		}
+		switch parts[1] {
+		case "k":
+			_ = t.l1.Delete(context.Background(), parts[2])
+		case "p":
+			_, _ = t.l1.DeletePrefix(context.Background(), parts[2])
+		}
+	}
+}
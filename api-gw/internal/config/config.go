@@ -7,9 +7,65 @@ import (
 )
 
 type Config struct {
+	// HTTP server
+	HTTPAddr                string
+	HTTPReadTimeoutSeconds  int
+	HTTPWriteTimeoutSeconds int
+	HTTPIdleTimeoutSeconds  int
+	ShutdownDelaySeconds    int    // keep serving after /readyz flips, before closing the listener
+	ShutdownDrainSeconds    int    // max wait for in-flight requests
+	MetricsAddr             string // separate /metrics listener; empty serves it on HTTPAddr, admins only
+
 	EngineAddr string
 	LogicAddr  string
 
+	// Engine (Thrift) connection pool
+	EnginePoolMaxIdle            int
+	EnginePoolMaxOpen            int
+	EnginePoolIdleTimeoutSeconds int
+
+	// Engine (Thrift) load balancing across ENGINE_ADDR endpoints
+	EngineLBPolicy           string // round_robin | least_outstanding
+	EngineResolveSeconds     int
+	EngineHealthSeconds      int
+	EngineEjectAfterFailures int
+	EngineEjectSeconds       int // cool-down before re-admission when health checks are off
+
+	// Logic (gRPC) load balancing across LOGIC_ADDR endpoints
+	LogicLBPolicy    string // round_robin | least_request | pick_first
+	LogicHealthCheck bool
+
+	// Logic (gRPC) channel keepalive
+	LogicKeepaliveTimeSeconds    int
+	LogicKeepaliveTimeoutSeconds int
+
+	// Logic request timeouts per route
+	LogicEvalTimeoutSeconds      int
+	LogicTransformTimeoutSeconds int
+	LogicPlanTimeoutSeconds      int
+	LogicBatchTimeoutSeconds     int
+
+	// RPC resilience (both backends)
+	RPCRetryAttempts   int
+	RPCRetryBaseMillis int
+	RPCRetryMaxMillis  int
+	BreakerFailures    int
+	BreakerOpenSeconds int
+
+	// Readiness (/readyz)
+	ReadyCritical      []string // mysql | redis-sessions | redis-cache | engine | logic
+	ReadyCacheMillis   int
+	ReadyTimeoutMillis int
+
+	// Logging
+	LogLevel  string // debug | info | warn | error
+	LogFormat string // json | text
+
+	// Tracing (OTLP endpoint etc. via the standard OTEL_EXPORTER_OTLP_* vars)
+	TracingExporter    string // none | stdout | otlp
+	TracingServiceName string
+	TracingSampleRatio float64
+
 	// Auth / Cookie
 	SessionSecret  string // used to namespace/rotate sessions (not strictly required for opaque tokens but good to have)
 	CookieName     string
@@ -18,11 +74,75 @@ type Config struct {
 	CookieMaxAge   int // seconds
 	DBDSN          string
 	RedisAddr      string
-	SessionBackend string // "redis" | "memory"
+	SessionBackend string   // "redis" | "memory"
+	AdminUsers     []string // may use /api/admin and /metrics
 
 	// Cache
-	CacheBackend    string // "redis" | "memory"
-	CacheTTLSeconds int
+	CacheBackend      string // "redis" | "tiered" | "lru" | "memory"
+	CacheTTLSeconds   int
+	CacheStaleSeconds int    // default stale-while-revalidate window
+	CacheNegativeTTL  int    // seconds to cache replies carrying a domain error
+	CachePolicies     string // per-route overrides: "engine:matmul=5m+1h,logic:plan=30s"
+	CacheCodec        string // "binary" (protobuf/Thrift) | "json"
+	CacheCompressMin  int    // gzip payloads larger than this many bytes; 0 disables
+
+	// Engine admission control: hard per-request limits (0 disables) and a
+	// concurrency limiter for calls costing at least EngineHeavyCost
+	EngineMaxElements     int
+	EngineMaxPiSamples    int64
+	EngineMaxMatMulCost   float64
+	EngineHeavyCost       float64
+	EngineMaxInFlight     int
+	EngineMaxQueue        int
+	EngineQueueWaitMillis int
+
+	// Background jobs (/api/jobs)
+	JobBackend               string // mysql | redis
+	JobWorkers               int
+	JobQueueSize             int
+	JobDefaultTimeoutSeconds int
+	JobMaxTimeoutSeconds     int
+	JobRetentionHours        int
+	JobHeartbeatSeconds      int // SSE heartbeat on /api/jobs/{id}/events
+
+	// Computation history (/api/history)
+	HistoryEnabled         bool
+	HistoryMaxRequestBytes int
+	HistoryMaxResultBytes  int
+	HistoryRetentionDays   int // 0 keeps entries forever
+	HistoryPurgeMinutes    int
+
+	// Datasets (/api/datasets)
+	DatasetBackend     string // mysql | disk
+	DatasetDir         string // disk only
+	DatasetMaxBytes    int64  // upload size
+	DatasetMaxElements int
+	DatasetGCMinutes   int // sweep for contents no dataset refers to
+	DatasetGCGraceMins int // unreferenced contents put this recently are kept
+
+	// Batch routes (…:batch)
+	BatchMaxItems    int
+	BatchConcurrency int
+
+	// Pipelines (/api/pipeline)
+	PipelineMaxSteps       int
+	PipelineTimeoutSeconds int
+
+	// Rate limiting: per-route token buckets ("route=count/period[+burst]")
+	// keyed by session user, per-IP buckets for login/register, and an
+	// hourly compute-unit quota per user
+	RateLimitEnabled    bool
+	RateLimitBackend    string // memory | redis
+	RateLimits          string
+	RateLimitsIP        string
+	ComputeQuotaPerHour float64
+	ComputeQuotaBurst   float64 // 0 means the hourly amount
+
+	// Bounded in-memory cache (CACHE_BACKEND=lru, and the L1 of "tiered")
+	CacheMaxEntries   int
+	CacheMaxBytes     int64
+	CacheSweepSeconds int
+	CacheL1TTLSeconds int // tiered only: cap on how long L1 serves a key
 }
 
 func get(key, def string) string {
@@ -56,15 +176,125 @@ func getInt(key string, def int) int {
 	return i
 }
 
+func getFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+func getList(key, def string) []string {
+	var out []string
+	for _, v := range strings.Split(get(key, def), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func Load() Config {
 	// Try to guess cookie domain if provided like "api.localhost"
 	domain := strings.TrimSpace(os.Getenv("COOKIE_DOMAIN"))
 
 	return Config{
 		// Defaults that work nicely inside Docker Compose; override on host
+		HTTPAddr:                get("HTTP_ADDR", ":8080"),
+		HTTPReadTimeoutSeconds:  getInt("HTTP_READ_TIMEOUT_SECONDS", 15),
+		HTTPWriteTimeoutSeconds: getInt("HTTP_WRITE_TIMEOUT_SECONDS", 60),
+		HTTPIdleTimeoutSeconds:  getInt("HTTP_IDLE_TIMEOUT_SECONDS", 120),
+		ShutdownDelaySeconds:    getInt("SHUTDOWN_DELAY_SECONDS", 5),
+		ShutdownDrainSeconds:    getInt("SHUTDOWN_DRAIN_SECONDS", 25),
+		MetricsAddr:             get("METRICS_ADDR", ""),
+
 		EngineAddr: get("ENGINE_ADDR", "localhost:9101"),
 		LogicAddr:  get("LOGIC_ADDR", "localhost:9002"),
 
+		EnginePoolMaxIdle:            getInt("ENGINE_POOL_MAX_IDLE", 4),
+		EnginePoolMaxOpen:            getInt("ENGINE_POOL_MAX_OPEN", 16),
+		EnginePoolIdleTimeoutSeconds: getInt("ENGINE_POOL_IDLE_TIMEOUT_SECONDS", 90),
+
+		EngineLBPolicy:           get("ENGINE_LB_POLICY", "round_robin"),
+		EngineResolveSeconds:     getInt("ENGINE_RESOLVE_SECONDS", 30),
+		EngineHealthSeconds:      getInt("ENGINE_HEALTH_SECONDS", 5),
+		EngineEjectAfterFailures: getInt("ENGINE_EJECT_AFTER_FAILURES", 3),
+		EngineEjectSeconds:       getInt("ENGINE_EJECT_SECONDS", 30),
+
+		LogicLBPolicy:    get("LOGIC_LB_POLICY", "round_robin"),
+		LogicHealthCheck: getBool("LOGIC_HEALTH_CHECK", true),
+
+		LogicKeepaliveTimeSeconds:    getInt("LOGIC_KEEPALIVE_TIME_SECONDS", 60),
+		LogicKeepaliveTimeoutSeconds: getInt("LOGIC_KEEPALIVE_TIMEOUT_SECONDS", 10),
+
+		LogicEvalTimeoutSeconds:      getInt("LOGIC_EVAL_TIMEOUT_SECONDS", 3),
+		LogicTransformTimeoutSeconds: getInt("LOGIC_TRANSFORM_TIMEOUT_SECONDS", 5),
+		LogicPlanTimeoutSeconds:      getInt("LOGIC_PLAN_TIMEOUT_SECONDS", 8),
+		LogicBatchTimeoutSeconds:     getInt("LOGIC_BATCH_TIMEOUT_SECONDS", 10),
+
+		RPCRetryAttempts:   getInt("RPC_RETRY_ATTEMPTS", 3),
+		RPCRetryBaseMillis: getInt("RPC_RETRY_BASE_MS", 50),
+		RPCRetryMaxMillis:  getInt("RPC_RETRY_MAX_MS", 1000),
+		BreakerFailures:    getInt("BREAKER_FAILURES", 5),
+		BreakerOpenSeconds: getInt("BREAKER_OPEN_SECONDS", 10),
+
+		ReadyCritical:      getList("READY_CRITICAL", "mysql,redis-sessions,redis-cache,engine,logic"),
+		ReadyCacheMillis:   getInt("READY_CACHE_MS", 2000),
+		ReadyTimeoutMillis: getInt("READY_TIMEOUT_MS", 1000),
+
+		LogLevel:  get("LOG_LEVEL", "info"),
+		LogFormat: get("LOG_FORMAT", "json"),
+
+		TracingExporter:    get("TRACING_EXPORTER", "none"),
+		TracingServiceName: get("TRACING_SERVICE_NAME", "harmonia-api-gw"),
+		TracingSampleRatio: getFloat("TRACING_SAMPLE_RATIO", 1),
+
+		EngineMaxElements:     getInt("ENGINE_MAX_ELEMENTS", 1_000_000),
+		EngineMaxPiSamples:    int64(getFloat("ENGINE_MAX_PI_SAMPLES", 5e8)),
+		EngineMaxMatMulCost:   getFloat("ENGINE_MAX_MATMUL_COST", 1e9),
+		EngineHeavyCost:       getFloat("ENGINE_HEAVY_COST", 1e7),
+		EngineMaxInFlight:     getInt("ENGINE_MAX_IN_FLIGHT", 8),
+		EngineMaxQueue:        getInt("ENGINE_MAX_QUEUE", 32),
+		EngineQueueWaitMillis: getInt("ENGINE_QUEUE_WAIT_MS", 2000),
+
+		JobBackend:               get("JOB_BACKEND", "mysql"), // or "redis"
+		JobWorkers:               getInt("JOB_WORKERS", 4),
+		JobQueueSize:             getInt("JOB_QUEUE_SIZE", 256),
+		JobDefaultTimeoutSeconds: getInt("JOB_DEFAULT_TIMEOUT_SECONDS", 60),
+		JobMaxTimeoutSeconds:     getInt("JOB_MAX_TIMEOUT_SECONDS", 900),
+		JobRetentionHours:        getInt("JOB_RETENTION_HOURS", 168),
+		JobHeartbeatSeconds:      getInt("JOB_HEARTBEAT_SECONDS", 15),
+
+		HistoryEnabled:         getBool("HISTORY_ENABLED", true),
+		HistoryMaxRequestBytes: getInt("HISTORY_MAX_REQUEST_BYTES", 1<<20),
+		HistoryMaxResultBytes:  getInt("HISTORY_MAX_RESULT_BYTES", 64<<10),
+		HistoryRetentionDays:   getInt("HISTORY_RETENTION_DAYS", 90),
+		HistoryPurgeMinutes:    getInt("HISTORY_PURGE_MINUTES", 60),
+
+		DatasetBackend:     get("DATASET_BACKEND", "mysql"), // or "disk"
+		DatasetDir:         get("DATASET_DIR", "./data/datasets"),
+		DatasetMaxBytes:    int64(getInt("DATASET_MAX_BYTES", 64<<20)),
+		DatasetMaxElements: getInt("DATASET_MAX_ELEMENTS", 5_000_000),
+		DatasetGCMinutes:   getInt("DATASET_GC_MINUTES", 60),
+		DatasetGCGraceMins: getInt("DATASET_GC_GRACE_MINUTES", 60),
+
+		BatchMaxItems:    getInt("BATCH_MAX_ITEMS", 100),
+		BatchConcurrency: getInt("BATCH_CONCURRENCY", 8),
+
+		PipelineMaxSteps:       getInt("PIPELINE_MAX_STEPS", 32),
+		PipelineTimeoutSeconds: getInt("PIPELINE_TIMEOUT_SECONDS", 30),
+
+		RateLimitEnabled:    getBool("RATE_LIMIT_ENABLED", true),
+		RateLimitBackend:    get("RATE_LIMIT_BACKEND", "memory"), // or "redis"
+		RateLimits:          get("RATE_LIMITS", "default=120/m+60,engine:matmul=30/m+10"),
+		RateLimitsIP:        get("RATE_LIMITS_IP", "auth:login=10/m+5,auth:register=5/h+3"),
+		ComputeQuotaPerHour: getFloat("COMPUTE_QUOTA_PER_HOUR", 5e10),
+		ComputeQuotaBurst:   getFloat("COMPUTE_QUOTA_BURST", 0),
+
 		SessionSecret:  get("SESSION_SECRET", "dev-secret-change-me"),
 		CookieName:     get("COOKIE_NAME", "harmonia_session"),
 		CookieDomain:   domain,
@@ -73,8 +303,19 @@ func Load() Config {
 		DBDSN:          get("DB_DSN", "harmonia:harmonia@tcp(localhost:3306)/harmonia?parseTime=true"),
 		RedisAddr:      get("REDIS_ADDR", "localhost:6379"),
 		SessionBackend: get("SESSION_BACKEND", "memory"), // or "redis"
+		AdminUsers:     getList("ADMIN_USERS", ""),       // opt-in; never a seeded dev account
+
+		CacheBackend:      get("CACHE_BACKEND", "memory"), // or "redis" | "tiered" | "lru"
+		CacheTTLSeconds:   getInt("CACHE_TTL_SECONDS", 60),
+		CacheStaleSeconds: getInt("CACHE_STALE_SECONDS", 0),
+		CacheNegativeTTL:  getInt("CACHE_NEGATIVE_TTL_SECONDS", 10),
+		CachePolicies:     get("CACHE_POLICIES", ""),
+		CacheCodec:        get("CACHE_CODEC", "binary"),
+		CacheCompressMin:  getInt("CACHE_COMPRESS_MIN_BYTES", 4096),
 
-		CacheBackend:    get("CACHE_BACKEND", "memory"), // or "redis"
-		CacheTTLSeconds: getInt("CACHE_TTL_SECONDS", 60),
+		CacheMaxEntries:   getInt("CACHE_MAX_ENTRIES", 10000),
+		CacheMaxBytes:     int64(getInt("CACHE_MAX_BYTES", 64<<20)),
+		CacheSweepSeconds: getInt("CACHE_SWEEP_SECONDS", 30),
+		CacheL1TTLSeconds: getInt("CACHE_L1_TTL_SECONDS", 30),
 	}
 }
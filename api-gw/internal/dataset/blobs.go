@@ -0,0 +1,169 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Blobs holds dataset contents addressed by their SHA-256, so versions and
+// users uploading the same values share one copy. Every blob carries the
+// time it was last Put; Store.Sweep deletes blobs no dataset refers to once
+// that time is past a grace period, so a blob an upload has just put (or
+// re-put) is never removed before the upload's row exists.
+type Blobs interface {
+	// Put stores raw, or refreshes the time of the copy already stored.
+	Put(ctx context.Context, sum string, raw []byte) error
+	Get(ctx context.Context, sum string) ([]byte, error) // ErrBlobMissing if absent
+	// Stale lists the blobs last Put before cutoff.
+	Stale(ctx context.Context, cutoff time.Time) ([]string, error)
+	// DeleteStale deletes a blob unless it was Put at or after cutoff.
+	DeleteStale(ctx context.Context, sum string, cutoff time.Time) error
+}
+
+// MySQLBlobs keeps contents in the dataset_blobs table.
+type MySQLBlobs struct{ db *sql.DB }
+
+func NewMySQLBlobs(db *sql.DB) *MySQLBlobs { return &MySQLBlobs{db: db} }
+
+func (b *MySQLBlobs) Put(ctx context.Context, sum string, raw []byte) error {
+	now := time.Now().UTC()
+	_, err := b.db.ExecContext(ctx,
+		`INSERT INTO dataset_blobs (sha256, data, touched_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE touched_at=?`,
+		sum, raw, now, now)
+	return err
+}
+
+func (b *MySQLBlobs) Get(ctx context.Context, sum string) ([]byte, error) {
+	var raw []byte
+	err := b.db.QueryRowContext(ctx, `SELECT data FROM dataset_blobs WHERE sha256=?`, sum).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBlobMissing
+	}
+	return raw, err
+}
+
+func (b *MySQLBlobs) Stale(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := b.db.QueryContext(ctx, `SELECT sha256 FROM dataset_blobs WHERE touched_at < ?`, cutoff.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var sum string
+		if err := rows.Scan(&sum); err != nil {
+			return nil, err
+		}
+		out = append(out, sum)
+	}
+	return out, rows.Err()
+}
+
+func (b *MySQLBlobs) DeleteStale(ctx context.Context, sum string, cutoff time.Time) error {
+	_, err := b.db.ExecContext(ctx, `DELETE FROM dataset_blobs WHERE sha256=? AND touched_at < ?`, sum, cutoff.UTC())
+	return err
+}
+
+// DiskBlobs keeps contents as files under dir, fanned out by the first two
+// hex digits of the hash. A file's modification time is its Put time. The
+// check-then-delete in DeleteStale is atomic with Put within one process;
+// gateways sharing dir rely on the grace period alone.
+type DiskBlobs struct {
+	dir string
+	mu  sync.Mutex // orders Put's refresh against DeleteStale
+}
+
+func NewDiskBlobs(dir string) (*DiskBlobs, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &DiskBlobs{dir: dir}, nil
+}
+
+func (b *DiskBlobs) path(sum string) string {
+	return filepath.Join(b.dir, sum[:2], sum)
+}
+
+func (b *DiskBlobs) Put(_ context.Context, sum string, raw []byte) error {
+	p := b.path(sum)
+	b.mu.Lock()
+	now := time.Now()
+	err := os.Chtimes(p, now, now)
+	b.mu.Unlock()
+	if err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	// write then rename, so a reader never sees a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(p), sum+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (b *DiskBlobs) Get(_ context.Context, sum string) ([]byte, error) {
+	raw, err := os.ReadFile(b.path(sum))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobMissing
+	}
+	return raw, err
+}
+
+func (b *DiskBlobs) Stale(ctx context.Context, cutoff time.Time) ([]string, error) {
+	var out []string
+	err := filepath.WalkDir(b.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := d.Name()
+		if len(name) != 64 || strings.HasSuffix(name, ".tmp") {
+			return nil // in-flight temp files
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed meanwhile
+		}
+		if info.ModTime().Before(cutoff) {
+			out = append(out, name)
+		}
+		return ctx.Err()
+	})
+	return out, err
+}
+
+func (b *DiskBlobs) DeleteStale(_ context.Context, sum string, cutoff time.Time) error {
+	p := b.path(sum)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil || !info.ModTime().Before(cutoff) {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+// Package dataset stores named vectors and matrices per user so engine and
+// logic requests can reference them by ID instead of sending data inline.
+package dataset
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("dataset not found")
+	ErrBlobMissing = errors.New("dataset contents are missing")
+)
+
+const (
+	Vector = "vector"
+	Matrix = "matrix"
+)
+
+// Dataset describes one immutable version of a named dataset. Uploading to
+// an existing name adds a version with a new ID; requests reference IDs, so
+// a result never changes under them.
+type Dataset struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Kind      string    `json:"kind"` // vector | matrix
+	Rows      int       `json:"rows"` // 1 for a vector
+	Cols      int       `json:"cols"` // the length, for a vector
+	Format    string    `json:"format"`
+	SHA256    string    `json:"sha256"` // of the values as little-endian float64
+	Bytes     int64     `json:"bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (d *Dataset) Len() int { return d.Rows * d.Cols }
+
+// Values is parsed upload content, row-major.
+type Values struct {
+	Rows, Cols int
+	Matrix     bool
+	Data       []float64
+}
+
+func (v *Values) kind() string {
+	if v.Matrix {
+		return Matrix
+	}
+	return Vector
+}
+
+// encode lays the values out as little-endian float64s; the content hash
+// and the stored blob are both over these bytes.
+func encode(data []float64) []byte {
+	raw := make([]byte, 8*len(data))
+	for i, f := range data {
+		binary.LittleEndian.PutUint64(raw[8*i:], math.Float64bits(f))
+	}
+	return raw
+}
+
+func decode(raw []byte) ([]float64, error) {
+	if len(raw)%8 != 0 {
+		return nil, errors.New("dataset blob is not a float64 array")
+	}
+	data := make([]float64, len(raw)/8)
+	for i := range data {
+		data[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[8*i:]))
+	}
+	return data, nil
+}
+
+func hash(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
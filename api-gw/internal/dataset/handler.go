@@ -0,0 +1,209 @@
+package dataset
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+)
+
+type Config struct {
+	MaxBytes    int64 // upload size
+	MaxElements int   // values per dataset
+}
+
+type Controller struct {
+	store *Store
+	cfg   Config
+}
+
+func NewController(store *Store, cfg Config) *Controller {
+	return &Controller{store: store, cfg: cfg}
+}
+
+func Register(rg *gin.RouterGroup, ctrl *Controller) {
+	g := rg.Group("/datasets")
+	g.POST("", ctrl.Upload)
+	g.GET("", ctrl.List)
+	g.GET("/:id", ctrl.Describe)
+	g.GET("/:id/data", ctrl.Data)
+	g.DELETE("/:id", ctrl.Delete)
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// Upload godoc
+// @Summary      Upload a dataset
+// @Description  Stores a vector or matrix under a name, as multipart (field "file") or as the raw body. JSON takes [..], [[..], ..] or {rows, cols, data}; CSV takes one row per line (optional header; one row or column is a vector); .npy takes a 1-D or 2-D numeric array. Uploading to an existing name adds a version, unless the values equal the latest one. Reference the returned ID as "dataset_id" in place of "data" (or of a whole matrix) in engine and logic requests.
+// @Tags         datasets
+// @Accept       mpfd
+// @Accept       json
+// @Produce      json
+// @Param        name    query     string  true   "Dataset name (letters, digits, . _ -)"
+// @Param        format  query     string  false  "json | csv | npy (default: from the file name or Content-Type)"
+// @Param        file    formData  file    false  "The file, for multipart uploads"
+// @Success      201     {object}  Dataset
+// @Success      200     {object}  Dataset  "Unchanged: the latest version holds the same values"
+// @Failure      400     {object}  map[string]string
+// @Failure      413     {object}  map[string]string
+// @Router       /datasets [post]
+func (c *Controller) Upload(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.cfg.MaxBytes)
+	name, filename, contentType := ctx.Query("name"), "", ctx.ContentType()
+
+	var raw []byte
+	var err error
+	if strings.HasPrefix(contentType, "multipart/") {
+		if name == "" {
+			name = ctx.PostForm("name")
+		}
+		fh, ferr := ctx.FormFile("file")
+		if ferr != nil {
+			err = ferr
+		} else {
+			filename, contentType = fh.Filename, fh.Header.Get("Content-Type")
+			if f, oerr := fh.Open(); oerr != nil {
+				err = oerr
+			} else {
+				raw, err = io.ReadAll(f)
+				f.Close()
+			}
+		}
+	} else {
+		raw, err = io.ReadAll(ctx.Request.Body)
+	}
+	var tooBig *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooBig):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "upload exceeds " + strconv.FormatInt(c.cfg.MaxBytes, 10) + " bytes", "request_id": requestid.Get(ctx)})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid upload: " + err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	}
+	if !validName.MatchString(name) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "name is required: up to 128 letters, digits, '.', '_' or '-'", "request_id": requestid.Get(ctx)})
+		return
+	}
+	format, err := DetectFormat(ctx.Query("format"), filename, contentType)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	}
+	v, err := Parse(format, raw, c.cfg.MaxElements)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	}
+
+	d, created, err := c.store.Create(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), name, format, v)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store dataset", "request_id": requestid.Get(ctx)})
+		return
+	}
+	if !created {
+		ctx.JSON(http.StatusOK, d)
+		return
+	}
+	ctx.Header("Location", ctx.FullPath()+"/"+d.ID)
+	ctx.JSON(http.StatusCreated, d)
+}
+
+// List godoc
+// @Summary      List my datasets
+// @Description  The caller's datasets, newest first; name narrows it to the versions of one dataset
+// @Tags         datasets
+// @Produce      json
+// @Param        name   query  string  false  "Dataset name"
+// @Param        limit  query  int     false  "Maximum number of datasets"  default(100)
+// @Success      200    {object}  map[string]any
+// @Router       /datasets [get]
+func (c *Controller) List(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	list, err := c.store.List(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), ctx.Query("name"), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list datasets", "request_id": requestid.Get(ctx)})
+		return
+	}
+	if list == nil {
+		list = []*Dataset{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"datasets": list})
+}
+
+// Describe godoc
+// @Summary      Describe a dataset
+// @Description  Shape, version, content hash and size, plus the first values
+// @Tags         datasets
+// @Produce      json
+// @Param        id   path  string  true  "Dataset ID"
+// @Success      200  {object}  map[string]any
+// @Failure      404  {object}  map[string]string
+// @Router       /datasets/{id} [get]
+func (c *Controller) Describe(ctx *gin.Context) {
+	d, data, ok := c.load(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"dataset": d, "preview": data[:min(len(data), 10)]})
+}
+
+// Data godoc
+// @Summary      Dataset values
+// @Description  The values row-major, with the shape
+// @Tags         datasets
+// @Produce      json
+// @Param        id   path  string  true  "Dataset ID"
+// @Success      200  {object}  map[string]any
+// @Failure      404  {object}  map[string]string
+// @Router       /datasets/{id}/data [get]
+func (c *Controller) Data(ctx *gin.Context) {
+	d, data, ok := c.load(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"id": d.ID, "kind": d.Kind, "rows": d.Rows, "cols": d.Cols, "data": data})
+}
+
+// Delete godoc
+// @Summary      Delete a dataset version
+// @Tags         datasets
+// @Produce      json
+// @Param        id   path  string  true  "Dataset ID"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /datasets/{id} [delete]
+func (c *Controller) Delete(ctx *gin.Context) {
+	err := c.store.Delete(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), ctx.Param("id"))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete dataset", "request_id": requestid.Get(ctx)})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"deleted": ctx.Param("id")})
+	}
+}
+
+func (c *Controller) load(ctx *gin.Context) (*Dataset, []float64, bool) {
+	d, data, err := c.store.Load(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), ctx.Param("id"))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return nil, nil, false
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load dataset", "request_id": requestid.Get(ctx)})
+		return nil, nil, false
+	}
+	return d, data, true
+}
@@ -0,0 +1,301 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Formats are the upload formats Parse understands.
+var Formats = []string{"json", "csv", "npy"}
+
+// DetectFormat picks the format from an explicit name, the file extension
+// or the content type, in that order.
+func DetectFormat(explicit, filename, contentType string) (string, error) {
+	if explicit != "" {
+		for _, f := range Formats {
+			if strings.EqualFold(explicit, f) {
+				return f, nil
+			}
+		}
+		return "", fmt.Errorf("unknown format %q: want json, csv or npy", explicit)
+	}
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".json":
+		return "json", nil
+	case ".csv", ".txt":
+		return "csv", nil
+	case ".npy":
+		return "npy", nil
+	}
+	ct, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(ct)) {
+	case "application/json":
+		return "json", nil
+	case "text/csv", "text/plain":
+		return "csv", nil
+	case "application/x-npy", "application/octet-stream":
+		return "npy", nil
+	}
+	return "", errors.New("cannot tell the format; pass format=json|csv|npy")
+}
+
+// Parse reads a vector or matrix in the given format, refusing more than
+// maxElements values and non-finite ones.
+func Parse(format string, raw []byte, maxElements int) (*Values, error) {
+	var (
+		v   *Values
+		err error
+	)
+	switch format {
+	case "json":
+		v, err = parseJSON(raw)
+	case "csv":
+		v, err = parseCSV(raw)
+	case "npy":
+		v, err = parseNPY(raw, maxElements)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Data) == 0 {
+		return nil, errors.New("dataset is empty")
+	}
+	if maxElements > 0 && len(v.Data) > maxElements {
+		return nil, fmt.Errorf("dataset has %d values; the limit is %d", len(v.Data), maxElements)
+	}
+	for i, f := range v.Data {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("value %d is not a finite number", i)
+		}
+	}
+	return v, nil
+}
+
+// parseJSON accepts [1, 2, 3], [[1, 2], [3, 4]] or
+// {"rows": 2, "cols": 2, "data": [1, 2, 3, 4]}.
+func parseJSON(raw []byte) (*Values, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		var m struct {
+			Rows int       `json:"rows"`
+			Cols int       `json:"cols"`
+			Data []float64 `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil, fmt.Errorf("invalid JSON matrix: %w", err)
+		}
+		if m.Rows <= 0 || m.Cols <= 0 || m.Rows*m.Cols != len(m.Data) {
+			return nil, errors.New("JSON matrix: data length must equal rows*cols")
+		}
+		return &Values{Rows: m.Rows, Cols: m.Cols, Matrix: true, Data: m.Data}, nil
+	}
+	var vec []float64
+	if err := json.Unmarshal(raw, &vec); err == nil {
+		return &Values{Rows: 1, Cols: len(vec), Data: vec}, nil
+	}
+	var rows [][]float64
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, errors.New("invalid JSON: want an array of numbers, an array of rows, or {rows, cols, data}")
+	}
+	return fromRows(rows)
+}
+
+// parseCSV reads one row per line. A first line that isn't numeric is taken
+// as a header; a single row or a single column is a vector.
+func parseCSV(raw []byte) (*Values, error) {
+	r := csv.NewReader(bytes.NewReader(raw))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var rows [][]float64
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		row := make([]float64, len(rec))
+		for i, field := range rec {
+			f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				if line == 1 {
+					row = nil
+					break
+				}
+				return nil, fmt.Errorf("CSV line %d, column %d: %q is not a number", line, i+1, field)
+			}
+			row[i] = f
+		}
+		if row != nil {
+			rows = append(rows, row)
+		}
+	}
+	v, err := fromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if v.Cols == 1 {
+		v.Rows, v.Cols, v.Matrix = 1, v.Rows, false
+	}
+	return v, nil
+}
+
+func fromRows(rows [][]float64) (*Values, error) {
+	if len(rows) == 0 {
+		return &Values{}, nil
+	}
+	cols := len(rows[0])
+	data := make([]float64, 0, len(rows)*cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, fmt.Errorf("row %d has %d values; row 1 has %d", i+1, len(row), cols)
+		}
+		data = append(data, row...)
+	}
+	if len(rows) == 1 {
+		return &Values{Rows: 1, Cols: cols, Data: data}, nil
+	}
+	return &Values{Rows: len(rows), Cols: cols, Matrix: true, Data: data}, nil
+}
+
+var (
+	npyMagic = []byte("\x93NUMPY")
+	npyDescr = regexp.MustCompile(`'descr'\s*:\s*'([<>|=])([fiub])(\d+)'`)
+	npyOrder = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShape = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// parseNPY reads a NumPy .npy file (format versions 1-3) holding a 1-D or
+// 2-D array of floats, integers or booleans.
+func parseNPY(raw []byte, maxElements int) (*Values, error) {
+	if len(raw) < 10 || !bytes.HasPrefix(raw, npyMagic) {
+		return nil, errors.New("not a .npy file")
+	}
+	var hlen, off int
+	switch raw[6] {
+	case 1:
+		hlen, off = int(binary.LittleEndian.Uint16(raw[8:10])), 10
+	case 2, 3:
+		if len(raw) < 12 {
+			return nil, errors.New("truncated .npy header")
+		}
+		hlen, off = int(binary.LittleEndian.Uint32(raw[8:12])), 12
+	default:
+		return nil, fmt.Errorf("unsupported .npy version %d", raw[6])
+	}
+	if off+hlen > len(raw) {
+		return nil, errors.New("truncated .npy header")
+	}
+	header, body := string(raw[off:off+hlen]), raw[off+hlen:]
+
+	d := npyDescr.FindStringSubmatch(header)
+	if d == nil {
+		return nil, errors.New(".npy: unsupported dtype; want a numeric array")
+	}
+	size, _ := strconv.Atoi(d[3])
+	var order binary.ByteOrder = binary.LittleEndian
+	if d[1] == ">" {
+		order = binary.BigEndian
+	}
+	read, err := npyReader(d[2], size, order)
+	if err != nil {
+		return nil, err
+	}
+	fortran := npyOrder.FindStringSubmatch(header)
+	s := npyShape.FindStringSubmatch(header)
+	if fortran == nil || s == nil {
+		return nil, errors.New(".npy: malformed header")
+	}
+	var shape []int
+	for _, part := range strings.Split(s[1], ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, errors.New(".npy: malformed shape")
+		}
+		shape = append(shape, n)
+	}
+	var rows, cols int
+	switch len(shape) {
+	case 1:
+		rows, cols = 1, shape[0]
+	case 2:
+		rows, cols = shape[0], shape[1]
+	default:
+		return nil, fmt.Errorf(".npy: %d-dimensional arrays are not supported; want 1-D or 2-D", len(shape))
+	}
+	// check each dimension before multiplying, so a hostile shape cannot
+	// wrap rows*cols
+	limit := len(body) / size
+	for _, dim := range []int{rows, cols} {
+		if maxElements > 0 && dim > maxElements {
+			return nil, fmt.Errorf("dataset has more than %d values; the limit is %d", maxElements, maxElements)
+		}
+		if dim > limit {
+			return nil, errors.New(".npy: data is shorter than its shape")
+		}
+	}
+	if rows > 0 && cols > limit/rows {
+		return nil, errors.New(".npy: data is shorter than its shape")
+	}
+	n := rows * cols
+	if maxElements > 0 && n > maxElements {
+		return nil, fmt.Errorf("dataset has %d values; the limit is %d", n, maxElements)
+	}
+
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = read(body[i*size:])
+	}
+	if fortran[1] == "True" && len(shape) == 2 {
+		rowMajor := make([]float64, n)
+		for c := 0; c < cols; c++ {
+			for r := 0; r < rows; r++ {
+				rowMajor[r*cols+c] = data[c*rows+r]
+			}
+		}
+		data = rowMajor
+	}
+	return &Values{Rows: rows, Cols: cols, Matrix: len(shape) == 2, Data: data}, nil
+}
+
+func npyReader(kind string, size int, order binary.ByteOrder) (func([]byte) float64, error) {
+	switch kind + strconv.Itoa(size) {
+	case "f8":
+		return func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }, nil
+	case "f4":
+		return func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }, nil
+	case "i8":
+		return func(b []byte) float64 { return float64(int64(order.Uint64(b))) }, nil
+	case "i4":
+		return func(b []byte) float64 { return float64(int32(order.Uint32(b))) }, nil
+	case "i2":
+		return func(b []byte) float64 { return float64(int16(order.Uint16(b))) }, nil
+	case "i1":
+		return func(b []byte) float64 { return float64(int8(b[0])) }, nil
+	case "u8":
+		return func(b []byte) float64 { return float64(order.Uint64(b)) }, nil
+	case "u4":
+		return func(b []byte) float64 { return float64(order.Uint32(b)) }, nil
+	case "u2":
+		return func(b []byte) float64 { return float64(order.Uint16(b)) }, nil
+	case "u1", "b1":
+		return func(b []byte) float64 { return float64(b[0]) }, nil
+	}
+	return nil, fmt.Errorf(".npy: unsupported dtype %s%d", kind, size)
+}
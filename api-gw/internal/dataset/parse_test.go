@@ -0,0 +1,155 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// npy builds a .npy file: header dict fields, then the values written with
+// the given byte order and encoding.
+func npy(version byte, descr, fortran, shape string, body []byte) []byte {
+	header := "{'descr': '" + descr + "', 'fortran_order': " + fortran + ", 'shape': (" + shape + "), }"
+	prefix := 10
+	if version > 1 {
+		prefix = 12
+	}
+	// pad to a multiple of 64 with spaces and a newline, as NumPy does
+	pad := 64 - (prefix+len(header)+1)%64
+	header += strings.Repeat(" ", pad%64) + "\n"
+
+	var buf bytes.Buffer
+	buf.Write(npyMagic)
+	buf.Write([]byte{version, 0})
+	if version > 1 {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(header)))
+	} else {
+		binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	}
+	buf.WriteString(header)
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func f8(order binary.ByteOrder, vals ...float64) []byte {
+	b := make([]byte, 8*len(vals))
+	for i, v := range vals {
+		order.PutUint64(b[8*i:], math.Float64bits(v))
+	}
+	return b
+}
+
+func f4(order binary.ByteOrder, vals ...float32) []byte {
+	b := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		order.PutUint32(b[4*i:], math.Float32bits(v))
+	}
+	return b
+}
+
+func i4(order binary.ByteOrder, vals ...int32) []byte {
+	b := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		order.PutUint32(b[4*i:], uint32(v))
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+	vec := func(data ...float64) *Values { return &Values{Rows: 1, Cols: len(data), Data: data} }
+	mat := func(rows, cols int, data ...float64) *Values {
+		return &Values{Rows: rows, Cols: cols, Matrix: true, Data: data}
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		raw     []byte
+		max     int
+		want    *Values
+		wantErr string
+	}{
+		{"json vector", "json", []byte(" [1, 2.5, -3] "), 0, vec(1, 2.5, -3), ""},
+		{"json rows", "json", []byte(`[[1, 2, 3], [4, 5, 6]]`), 0, mat(2, 3, 1, 2, 3, 4, 5, 6), ""},
+		{"json single row is a vector", "json", []byte(`[[1, 2]]`), 0, vec(1, 2), ""},
+		{"json object", "json", []byte(`{"rows": 2, "cols": 2, "data": [1, 2, 3, 4]}`), 0, mat(2, 2, 1, 2, 3, 4), ""},
+		{"json object wrong length", "json", []byte(`{"rows": 2, "cols": 2, "data": [1, 2, 3]}`), 0, nil, "rows*cols"},
+		{"json ragged rows", "json", []byte(`[[1, 2], [3]]`), 0, nil, "row 2 has 1 values"},
+		{"json not numbers", "json", []byte(`["a"]`), 0, nil, "invalid JSON"},
+		{"json empty", "json", []byte(`[]`), 0, nil, "empty"},
+		{"json over limit", "json", []byte(`[1, 2, 3]`), 2, nil, "the limit is 2"},
+
+		{"csv matrix", "csv", []byte("1,2\n3,4\n5,6\n"), 0, mat(3, 2, 1, 2, 3, 4, 5, 6), ""},
+		{"csv header", "csv", []byte("a, b\n1, 2\n3, 4\n"), 0, mat(2, 2, 1, 2, 3, 4), ""},
+		{"csv single row", "csv", []byte("1,2,3\n"), 0, vec(1, 2, 3), ""},
+		{"csv single column", "csv", []byte("x\n1\n2\n3\n"), 0, vec(1, 2, 3), ""},
+		{"csv ragged", "csv", []byte("1,2\n3\n"), 0, nil, "row 2 has 1 values"},
+		{"csv not a number", "csv", []byte("1,2\n3,x\n"), 0, nil, `line 2, column 2: "x"`},
+		{"csv header only", "csv", []byte("a,b\n"), 0, nil, "empty"},
+		{"csv not finite", "csv", []byte("1,NaN\n"), 0, nil, "not a finite number"},
+
+		{"npy f8 vector", "npy", npy(1, "<f8", "False", "3,", f8(le, 1, 2, 3)), 0, vec(1, 2, 3), ""},
+		{"npy f8 matrix", "npy", npy(1, "<f8", "False", "2, 3", f8(le, 1, 2, 3, 4, 5, 6)), 0, mat(2, 3, 1, 2, 3, 4, 5, 6), ""},
+		{"npy fortran order", "npy", npy(1, "<f8", "True", "2, 3", f8(le, 1, 4, 2, 5, 3, 6)), 0, mat(2, 3, 1, 2, 3, 4, 5, 6), ""},
+		{"npy big-endian f4", "npy", npy(1, ">f4", "False", "2,", f4(be, 1.5, -2)), 0, vec(1.5, -2), ""},
+		{"npy big-endian fortran i4", "npy", npy(1, ">i4", "True", "2, 2", i4(be, 1, 3, 2, 4)), 0, mat(2, 2, 1, 2, 3, 4), ""},
+		{"npy i4 negative", "npy", npy(1, "<i4", "False", "2,", i4(le, -7, 7)), 0, vec(-7, 7), ""},
+		{"npy bool", "npy", npy(1, "|b1", "False", "3,", []byte{1, 0, 1}), 0, vec(1, 0, 1), ""},
+		{"npy version 2", "npy", npy(2, "<f8", "False", "2,", f8(le, 1, 2)), 0, vec(1, 2), ""},
+		{"npy truncated data", "npy", npy(1, "<f8", "False", "3,", f8(le, 1, 2)), 0, nil, "shorter than its shape"},
+		{"npy overflowing shape", "npy", npy(1, "<f8", "False", "3074457345618258603, 3", f8(le, 1)), 0, nil, "shorter than its shape"},
+		{"npy huge dimension", "npy", npy(1, "<f8", "False", "4611686018427387904, 4", f8(le, 1)), 0, nil, "shorter than its shape"},
+		{"npy dimension over limit", "npy", npy(1, "<f8", "False", "1000000, 1", f8(le, 1)), 10, nil, "the limit is 10"},
+		{"npy product over limit", "npy", npy(1, "<f8", "False", "4, 4", f8(le, make([]float64, 16)...)), 10, nil, "the limit is 10"},
+		{"npy 3-d", "npy", npy(1, "<f8", "False", "1, 1, 1", f8(le, 1)), 0, nil, "3-dimensional"},
+		{"npy unsupported dtype", "npy", npy(1, "<c16", "False", "1,", make([]byte, 16)), 0, nil, "unsupported dtype"},
+		{"npy string dtype", "npy", npy(1, "<U4", "False", "1,", make([]byte, 16)), 0, nil, "unsupported dtype"},
+		{"npy bad magic", "npy", []byte("NUMPY\x01\x00\x00\x00\x00"), 0, nil, "not a .npy file"},
+		{"npy truncated header", "npy", npy(1, "<f8", "False", "1,", nil)[:20], 0, nil, "truncated .npy header"},
+
+		{"unknown format", "xml", []byte("<a/>"), 0, nil, "unknown format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, tt.raw, tt.max)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		explicit, filename, contentType string
+		want                            string
+		wantErr                         bool
+	}{
+		{"CSV", "a.json", "application/json", "csv", false},
+		{"xml", "", "", "", true},
+		{"", "data.NPY", "text/csv", "npy", false},
+		{"", "data.txt", "", "csv", false},
+		{"", "blob", "application/json; charset=utf-8", "json", false},
+		{"", "", "application/octet-stream", "npy", false},
+		{"", "data.bin", "image/png", "", true},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.explicit, tt.filename, tt.contentType)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("DetectFormat(%q, %q, %q) = %q, %v; want %q (error %v)", tt.explicit, tt.filename, tt.contentType, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+var refKey = []byte(`"dataset_id"`)
+
+// Resolve rewrites JSON request bodies that reference datasets: every
+// object holding "dataset_id" gets the dataset's values as "data" (and its
+// shape as "rows"/"cols" for a matrix) in place of the reference. It runs
+// before rate limiting, so quotas, admission limits and cache keys see the
+// same request as if the data had been sent inline; references nested in
+// job and pipeline inputs are resolved too. A nil Store resolves nothing.
+func (s *Store) Resolve() gin.HandlerFunc {
+	if s == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil || !bytes.Contains(body, refKey) {
+			c.Next()
+			return
+		}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if dec.Decode(&v) != nil {
+			c.Next() // the handler reports the bad payload
+			return
+		}
+
+		r := resolver{store: s, ctx: c.Request.Context(), user: c.GetString(auth.CtxUserKey), seen: map[string]*loaded{}}
+		err = r.walk(v)
+		var out []byte
+		if err == nil {
+			out, err = json.Marshal(v)
+		}
+		var rerr *rpcerr.Error
+		switch {
+		case errors.As(err, &rerr):
+			rpcerr.Respond(c, err)
+			c.Abort()
+			return
+		case err != nil:
+			slog.Warn("dataset: resolve failed", "err", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to load referenced dataset", "request_id": requestid.Get(c)})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(out))
+		c.Request.ContentLength = int64(len(out))
+		c.Next()
+	}
+}
+
+type loaded struct {
+	d    *Dataset
+	data []float64
+}
+
+type resolver struct {
+	store *Store
+	ctx   context.Context
+	user  string
+	seen  map[string]*loaded
+}
+
+func (r *resolver) walk(v any) error {
+	switch x := v.(type) {
+	case map[string]any:
+		if ref, ok := x["dataset_id"]; ok {
+			id, ok := ref.(string)
+			if !ok {
+				return rpcerr.Rejected("dataset", rpcerr.Invalid, "dataset_id must be a string")
+			}
+			l, err := r.load(id)
+			if err != nil {
+				return err
+			}
+			delete(x, "dataset_id")
+			x["data"] = l.data
+			if l.d.Kind == Matrix {
+				x["rows"], x["cols"] = l.d.Rows, l.d.Cols
+			}
+			return nil
+		}
+		for _, child := range x {
+			if err := r.walk(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range x {
+			if err := r.walk(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *resolver) load(id string) (*loaded, error) {
+	if l, ok := r.seen[id]; ok {
+		return l, nil
+	}
+	d, data, err := r.store.Load(r.ctx, r.user, id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil, rpcerr.Rejected("dataset", rpcerr.Invalid, fmt.Sprintf("dataset %q not found", id))
+	case err != nil:
+		return nil, fmt.Errorf("dataset %q: %w", id, err)
+	}
+	l := &loaded{d: d, data: data}
+	r.seen[id] = l
+	return l, nil
+}
@@ -0,0 +1,272 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+func RunMigrations(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS datasets (
+		id          CHAR(24)        PRIMARY KEY,
+		user_id     BIGINT UNSIGNED NOT NULL,
+		name        VARCHAR(128)    NOT NULL,
+		version     INT UNSIGNED    NOT NULL,
+		kind        VARCHAR(8)      NOT NULL,
+		n_rows      INT UNSIGNED    NOT NULL,
+		n_cols      INT UNSIGNED    NOT NULL,
+		format      VARCHAR(8)      NOT NULL,
+		sha256      CHAR(64)        NOT NULL,
+		bytes       BIGINT          NOT NULL,
+		created_at  DATETIME(3)     NOT NULL,
+		UNIQUE KEY uq_datasets_version (user_id, name, version),
+		KEY idx_datasets_sha256 (sha256),
+		CONSTRAINT fk_datasets_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		) ENGINE=InnoDB;`); err != nil {
+		return err
+	}
+	// used by MySQLBlobs only; harmless with disk storage
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS dataset_blobs (
+		sha256      CHAR(64)    PRIMARY KEY,
+		data        LONGBLOB    NOT NULL,
+		touched_at  DATETIME(3) NOT NULL,
+		KEY idx_dataset_blobs_touched (touched_at)
+		) ENGINE=InnoDB;`)
+	return err
+}
+
+// Store keeps dataset metadata in MySQL and contents in blobs. Callers pass
+// usernames; the user ID is resolved in the same statement.
+type Store struct {
+	db    *sql.DB
+	blobs Blobs
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func NewStore(db *sql.DB, blobs Blobs) *Store { return &Store{db: db, blobs: blobs} }
+
+const userID = `(SELECT id FROM users WHERE username=?)`
+
+const columns = `id, name, version, kind, n_rows, n_cols, format, sha256, bytes, created_at`
+
+// Create stores v as the next version of name. Re-uploading the values the
+// latest version already holds returns that version instead (created is
+// false).
+func (s *Store) Create(ctx context.Context, user, name, format string, v *Values) (*Dataset, bool, error) {
+	raw := encode(v.Data)
+	d := &Dataset{
+		ID:        newID(),
+		Name:      name,
+		Kind:      v.kind(),
+		Rows:      v.Rows,
+		Cols:      v.Cols,
+		Format:    format,
+		SHA256:    hash(raw),
+		Bytes:     int64(len(raw)),
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := s.blobs.Put(ctx, d.SHA256, raw); err != nil {
+		return nil, false, err
+	}
+
+	// Two uploads to one name can race for a version number; the unique key
+	// refuses the loser, which retries with the next one.
+	for attempt := 0; ; attempt++ {
+		latest, err := s.latest(ctx, user, name)
+		if err != nil {
+			return nil, false, err
+		}
+		d.Version = 1
+		if latest != nil {
+			if latest.SHA256 == d.SHA256 && latest.Rows == d.Rows && latest.Cols == d.Cols {
+				return latest, false, nil
+			}
+			d.Version = latest.Version + 1
+		}
+		res, err := s.db.ExecContext(ctx,
+			`INSERT INTO datasets (id, user_id, name, version, kind, n_rows, n_cols, format, sha256, bytes, created_at)
+			 SELECT ?, id, ?, ?, ?, ?, ?, ?, ?, ?, ? FROM users WHERE username=?`,
+			d.ID, d.Name, d.Version, d.Kind, d.Rows, d.Cols, d.Format, d.SHA256, d.Bytes, d.CreatedAt, user)
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return nil, false, errors.New("unknown user")
+		}
+		return d, true, nil
+	}
+}
+
+func (s *Store) latest(ctx context.Context, user, name string) (*Dataset, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+columns+` FROM datasets WHERE user_id=`+userID+` AND name=? ORDER BY version DESC LIMIT 1`, user, name)
+	d, err := scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// List returns the user's datasets, newest first; name narrows it to the
+// versions of one dataset.
+func (s *Store) List(ctx context.Context, user, name string, limit int) ([]*Dataset, error) {
+	q := `SELECT ` + columns + ` FROM datasets WHERE user_id=` + userID
+	args := []any{user}
+	if name != "" {
+		q += ` AND name=?`
+		args = append(args, name)
+	}
+	rows, err := s.db.QueryContext(ctx, q+` ORDER BY created_at DESC, version DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Dataset
+	for rows.Next() {
+		d, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Get(ctx context.Context, user, id string) (*Dataset, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+columns+` FROM datasets WHERE id=? AND user_id=`+userID, id, user)
+	d, err := scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return d, err
+}
+
+// Load returns a dataset and its values.
+func (s *Store) Load(ctx context.Context, user, id string) (*Dataset, []float64, error) {
+	d, err := s.Get(ctx, user, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := s.blobs.Get(ctx, d.SHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := decode(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) != d.Len() {
+		return nil, nil, ErrBlobMissing
+	}
+	return d, data, nil
+}
+
+// Delete removes one version. Its contents stay until Sweep finds no
+// dataset refers to them: deleting them here could race an upload of the
+// same values that has put the blob but not yet inserted its row.
+func (s *Store) Delete(ctx context.Context, user, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM datasets WHERE id=? AND user_id=`+userID, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Sweep deletes the blobs no dataset refers to that have not been Put for
+// grace, which must comfortably exceed the time an upload takes between
+// storing its blob and inserting its row.
+func (s *Store) Sweep(ctx context.Context, grace time.Duration) (int, error) {
+	cutoff := time.Now().Add(-grace)
+	stale, err := s.blobs.Stale(ctx, cutoff)
+	if err != nil || len(stale) == 0 {
+		return 0, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT sha256 FROM datasets`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	used := map[string]bool{}
+	for rows.Next() {
+		var sum string
+		if err := rows.Scan(&sum); err != nil {
+			return 0, err
+		}
+		used[sum] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, sum := range stale {
+		if used[sum] {
+			continue
+		}
+		// an upload that re-put the blob since Stale keeps it
+		if err := s.blobs.DeleteStale(ctx, sum, cutoff); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// StartSweeper runs Sweep every interval until Close.
+func (s *Store) StartSweeper(every, grace time.Duration) {
+	ctx, stop := context.WithCancel(context.Background())
+	s.stop = stop
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				sweepCtx, cancel := context.WithTimeout(ctx, time.Minute)
+				n, err := s.Sweep(sweepCtx, grace)
+				cancel()
+				if err != nil {
+					slog.Warn("dataset: sweep failed", "deleted", n, "err", err)
+				} else if n > 0 {
+					slog.Info("dataset: swept unused contents", "deleted", n)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the sweeper.
+func (s *Store) Close() error {
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+func scan(row interface{ Scan(...any) error }) (*Dataset, error) {
+	var d Dataset
+	if err := row.Scan(&d.ID, &d.Name, &d.Version, &d.Kind, &d.Rows, &d.Cols, &d.Format, &d.SHA256, &d.Bytes, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
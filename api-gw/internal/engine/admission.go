@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+// AdmissionConfig caps what a single request may ask of the engine. Limits
+// of zero are disabled.
+type AdmissionConfig struct {
+	MaxElements   int     // values in one matrix or stats vector (413 beyond)
+	MaxPiSamples  int64   // Pi cost (422 beyond)
+	MaxMatMulCost float64 // MatMul cost, rows×inner×cols (422 beyond)
+
+	// Calls costing at least HeavyCost take a slot from the limiter; cheaper
+	// ones go straight through.
+	HeavyCost   float64
+	Concurrency resilience.LimiterConfig
+}
+
+// admission rejects oversized requests and bounds how many expensive ones
+// reach the engine at once. Cache hits never take a slot.
+type admission struct {
+	cfg     AdmissionConfig
+	limiter *resilience.Limiter
+}
+
+func newAdmission(cfg AdmissionConfig) *admission {
+	return &admission{cfg: cfg, limiter: resilience.NewLimiter("engine", cfg.Concurrency)}
+}
+
+func (a *admission) checkPi(in PiDTO) error {
+	if a.cfg.MaxPiSamples > 0 && in.Samples > a.cfg.MaxPiSamples {
+		return rpcerr.Rejected("engine", rpcerr.TooCostly,
+			fmt.Sprintf("samples %d exceeds the limit of %d", in.Samples, a.cfg.MaxPiSamples))
+	}
+	return nil
+}
+
+func (a *admission) checkMatMul(in MatMulDTO) error {
+	if err := a.checkElements("matrix A", int64(len(in.A.Data))); err != nil {
+		return err
+	}
+	if err := a.checkElements("matrix B", int64(len(in.B.Data))); err != nil {
+		return err
+	}
+	if cost := in.Cost(); a.cfg.MaxMatMulCost > 0 && cost > a.cfg.MaxMatMulCost {
+		return rpcerr.Rejected("engine", rpcerr.TooCostly,
+			fmt.Sprintf("matmul cost %dx%dx%d = %.0f exceeds the limit of %.0f",
+				in.A.Rows, in.A.Cols, in.B.Cols, cost, a.cfg.MaxMatMulCost))
+	}
+	return nil
+}
+
+func (a *admission) checkStats(in StatsDTO) error {
+	return a.checkElements("data", int64(len(in.Data)))
+}
+
+func (a *admission) checkElements(what string, n int64) error {
+	if a.cfg.MaxElements > 0 && n > int64(a.cfg.MaxElements) {
+		return rpcerr.Rejected("engine", rpcerr.TooLarge,
+			fmt.Sprintf("%s has %d values; the limit is %d", what, n, a.cfg.MaxElements))
+	}
+	return nil
+}
+
+// run calls fn, first taking a limiter slot if cost makes the call heavy.
+func run[T any](ctx context.Context, a *admission, cost float64, fn func(context.Context) (T, error)) (T, error) {
+	if cost < a.cfg.HeavyCost {
+		return fn(ctx)
+	}
+	release, err := a.limiter.Acquire(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer release()
+	return fn(ctx)
+}
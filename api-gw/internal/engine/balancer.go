@@ -0,0 +1,302 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+var errNoEndpoints = errors.New("no engine endpoints")
+
+// BalancerConfig controls how calls are spread over engine replicas.
+//
+// The address spec is either a comma-separated list of host:port pairs or
+// "dns:///host:port", in which case host is re-resolved every ResolveEvery
+// and each returned IP becomes an endpoint.
+type BalancerConfig struct {
+	Policy       string        // "round_robin" (default) | "least_outstanding"
+	ResolveEvery time.Duration // dns:/// targets only
+	HealthEvery  time.Duration // active Hello probes; <= 0 disables
+	EjectAfter   int           // consecutive unavailable errors before ejection
+	EjectFor     time.Duration // without health probes, how long an ejected endpoint sits out
+}
+
+// EndpointStats describes one engine replica.
+type EndpointStats struct {
+	Addr        string    `json:"addr"`
+	Healthy     bool      `json:"healthy"`
+	Outstanding int64     `json:"outstanding"`
+	Pool        PoolStats `json:"pool"`
+}
+
+// BalancerStats is what /engine/pool reports.
+type BalancerStats struct {
+	Policy    string          `json:"policy"`
+	Endpoints []EndpointStats `json:"endpoints"`
+}
+
+type endpoint struct {
+	addr        string
+	pool        *pool
+	outstanding atomic.Int64
+	healthy     atomic.Bool
+	failures    atomic.Int32
+	ejectedAt   atomic.Int64 // Unix nanoseconds of the last passive ejection
+}
+
+type balancer struct {
+	cfg  BalancerConfig
+	pc   PoolConfig
+	dial func(addr string) (*conn, error)
+
+	mu        sync.RWMutex
+	endpoints []*endpoint
+	next      atomic.Uint64
+
+	stop chan struct{}
+	once sync.Once
+}
+
+func newBalancer(spec string, cfg BalancerConfig, pc PoolConfig, dial func(addr string) (*conn, error)) *balancer {
+	if cfg.EjectAfter <= 0 {
+		cfg.EjectAfter = 3
+	}
+	if cfg.EjectFor <= 0 {
+		cfg.EjectFor = 30 * time.Second
+	}
+	b := &balancer{cfg: cfg, pc: pc, dial: dial, stop: make(chan struct{})}
+
+	if host, ok := strings.CutPrefix(spec, "dns:///"); ok {
+		b.resolve(host)
+		if cfg.ResolveEvery > 0 {
+			go b.every(cfg.ResolveEvery, func() { b.resolve(host) })
+		}
+	} else {
+		var addrs []string
+		for _, a := range strings.Split(spec, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				addrs = append(addrs, a)
+			}
+		}
+		b.setAddrs(addrs)
+	}
+	if cfg.HealthEvery > 0 {
+		go b.every(cfg.HealthEvery, b.checkHealth)
+	}
+	return b
+}
+
+func (b *balancer) every(d time.Duration, fn func()) {
+	t := time.NewTicker(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-t.C:
+			fn()
+		}
+	}
+}
+
+func (b *balancer) resolve(hostport string) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		slog.Warn("engine: bad dns target", "target", hostport, "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		// keep the endpoints we have; DNS hiccups shouldn't empty the set
+		slog.Warn("engine: resolve failed", "host", host, "err", err)
+		return
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip, port))
+	}
+	b.setAddrs(addrs)
+}
+
+// setAddrs reconciles the endpoint set with addrs, keeping the pools (and
+// health state) of endpoints that are still listed.
+func (b *balancer) setAddrs(addrs []string) {
+	sort.Strings(addrs)
+	b.mu.Lock()
+	old := make(map[string]*endpoint, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		old[ep.addr] = ep
+	}
+	next := make([]*endpoint, 0, len(addrs))
+	for _, a := range addrs {
+		if ep, ok := old[a]; ok {
+			next = append(next, ep)
+			delete(old, a)
+			continue
+		}
+		ep := &endpoint{addr: a}
+		ep.pool = newPool(b.pc, func() (*conn, error) { return b.dial(a) })
+		ep.healthy.Store(true)
+		next = append(next, ep)
+		slog.Info("engine: endpoint added", "endpoint", a)
+	}
+	b.endpoints = next
+	b.mu.Unlock()
+
+	for _, ep := range old {
+		slog.Info("engine: endpoint removed", "endpoint", ep.addr)
+		ep.pool.close()
+	}
+}
+
+// pick chooses an endpoint among the healthy ones, or among all of them
+// when every endpoint is ejected (better to try than to fail outright).
+func (b *balancer) pick() (*endpoint, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.endpoints) == 0 {
+		return nil, errNoEndpoints
+	}
+	candidates := make([]*endpoint, 0, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		if ep.healthy.Load() || b.coolDown(ep) {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+	}
+
+	if b.cfg.Policy == "least_outstanding" {
+		best := candidates[0]
+		for _, ep := range candidates[1:] {
+			if ep.outstanding.Load() < best.outstanding.Load() {
+				best = ep
+			}
+		}
+		return best, nil
+	}
+	return candidates[b.next.Add(1)%uint64(len(candidates))], nil
+}
+
+// observe ejects an endpoint after EjectAfter consecutive unavailable
+// errors; the health checker re-admits it once it answers again. With health
+// checks off, a call that still reaches it (every endpoint ejected) and
+// succeeds re-admits it, and so does pick once EjectFor has passed.
+func (b *balancer) observe(ep *endpoint, err error) {
+	if rpcerr.KindOf(err) != rpcerr.Unavailable {
+		if err == nil || rpcerr.KindOf(err) == rpcerr.Invalid {
+			ep.failures.Store(0)
+			if b.cfg.HealthEvery <= 0 && !ep.healthy.Swap(true) {
+				slog.Info("engine: endpoint re-admitted", "endpoint", ep.addr)
+			}
+		}
+		return
+	}
+	if int(ep.failures.Add(1)) >= b.cfg.EjectAfter && ep.healthy.Swap(false) {
+		ep.ejectedAt.Store(time.Now().UnixNano())
+		slog.Warn("engine: endpoint ejected", "endpoint", ep.addr, "err", err)
+	}
+}
+
+// coolDown re-admits an endpoint ejected by observe once it has sat out
+// EjectFor, when no health checker would do so. It comes back on probation:
+// a single further unavailable error ejects it again.
+func (b *balancer) coolDown(ep *endpoint) bool {
+	if b.cfg.HealthEvery > 0 || time.Since(time.Unix(0, ep.ejectedAt.Load())) < b.cfg.EjectFor {
+		return false
+	}
+	if !ep.healthy.CompareAndSwap(false, true) {
+		return true
+	}
+	ep.failures.Store(int32(b.cfg.EjectAfter - 1))
+	slog.Info("engine: endpoint re-admitted", "endpoint", ep.addr, "after", b.cfg.EjectFor)
+	return true
+}
+
+func (b *balancer) checkHealth() {
+	b.mu.RLock()
+	eps := append([]*endpoint(nil), b.endpoints...)
+	b.mu.RUnlock()
+
+	for _, ep := range eps {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := ep.ping(ctx)
+		cancel()
+		if err != nil {
+			if ep.healthy.Swap(false) {
+				slog.Warn("engine: endpoint failed health check", "endpoint", ep.addr, "err", err)
+			}
+			continue
+		}
+		ep.failures.Store(0)
+		if !ep.healthy.Swap(true) {
+			slog.Info("engine: endpoint re-admitted", "endpoint", ep.addr)
+		}
+	}
+}
+
+// ping succeeds as soon as one endpoint answers Hello, trying healthy
+// endpoints first.
+func (b *balancer) ping(ctx context.Context) error {
+	b.mu.RLock()
+	eps := append([]*endpoint(nil), b.endpoints...)
+	b.mu.RUnlock()
+	sort.SliceStable(eps, func(i, j int) bool { return eps[i].healthy.Load() && !eps[j].healthy.Load() })
+
+	err := errNoEndpoints
+	for _, ep := range eps {
+		if err = ep.ping(ctx); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (ep *endpoint) ping(ctx context.Context) error {
+	return ep.pool.do(ctx, func(cn *conn) error {
+		_, err := cn.cli.Hello(ctx, &eng.HelloRequest{Name: "healthcheck"})
+		return err
+	})
+}
+
+func (b *balancer) stats() BalancerStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	policy := b.cfg.Policy
+	if policy == "" {
+		policy = "round_robin"
+	}
+	st := BalancerStats{Policy: policy, Endpoints: make([]EndpointStats, 0, len(b.endpoints))}
+	for _, ep := range b.endpoints {
+		st.Endpoints = append(st.Endpoints, EndpointStats{
+			Addr:        ep.addr,
+			Healthy:     ep.healthy.Load(),
+			Outstanding: ep.outstanding.Load(),
+			Pool:        ep.pool.stats(),
+		})
+	}
+	return st
+}
+
+func (b *balancer) close() {
+	b.once.Do(func() { close(b.stop) })
+	b.mu.Lock()
+	eps := b.endpoints
+	b.endpoints = nil
+	b.mu.Unlock()
+	for _, ep := range eps {
+		ep.pool.close()
+	}
+}
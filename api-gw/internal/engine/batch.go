@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+
+	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
+	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
+)
+
+// loadBatch admits each input, then resolves all of them through
+// cache.LoadMany: one bulk lookup, at most limit misses in flight.
+func loadBatch[In, Out any](ctx context.Context, s *Service, ins []In, limit int,
+	check func(In) error, key func(In) string, call func(context.Context, In) (*Out, error),
+) []cache.Item[Out] {
+	items := make([]cache.Item[Out], len(ins))
+	keys := make([]string, 0, len(ins))
+	idx := make([]int, 0, len(ins))
+	for i, in := range ins {
+		if err := check(in); err != nil {
+			items[i].Err = err
+			continue
+		}
+		keys = append(keys, key(in))
+		idx = append(idx, i)
+	}
+	loaded := cache.LoadMany(ctx, s.ld, keys, limit, func(ctx context.Context, j int) (*Out, error) {
+		return call(ctx, ins[idx[j]])
+	})
+	for j, it := range loaded {
+		items[idx[j]] = it
+	}
+	return items
+}
+
+func (s *Service) MatMulBatch(ctx context.Context, ins []MatMulDTO, limit int) []cache.Item[eng.MatReply] {
+	return loadBatch(ctx, s, ins, limit, s.adm.checkMatMul, matMulKey,
+		func(ctx context.Context, in MatMulDTO) (*eng.MatReply, error) {
+			a := &eng.Matrix{Rows: in.A.Rows, Cols: in.A.Cols, Data: in.A.Data}
+			b := &eng.Matrix{Rows: in.B.Rows, Cols: in.B.Cols, Data: in.B.Data}
+			return run(ctx, s.adm, in.Cost(), func(ctx context.Context) (*eng.MatReply, error) {
+				return s.c.matMul(ctx, a, b)
+			})
+		})
+}
+
+func (s *Service) ComputeStatsBatch(ctx context.Context, ins []StatsDTO, limit int) []cache.Item[eng.VectorStatsReply] {
+	return loadBatch(ctx, s, ins, limit, s.adm.checkStats, statsKey,
+		func(ctx context.Context, in StatsDTO) (*eng.VectorStatsReply, error) {
+			sample := true
+			if in.Sample != nil {
+				sample = *in.Sample
+			}
+			return run(ctx, s.adm, in.Cost(), func(ctx context.Context) (*eng.VectorStatsReply, error) {
+				return s.c.computeStats(ctx, in.Data, sample)
+			})
+		})
+}
@@ -5,16 +5,53 @@ import (
 	"time"
 
 	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
 	"github.com/apache/thrift/lib/go/thrift"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Client spreads engine RPCs over one or more replicas (see BalancerConfig),
+// each with its own connection pool.
 type Client struct {
-	addr string
+	lb     *balancer
+	res    *resilience.Executor
+	tracer trace.Tracer
 }
 
-func NewClient(addr string) *Client { return &Client{addr: addr} }
+func NewClient(addr string, pc PoolConfig, bc BalancerConfig, rc resilience.Config) *Client {
+	c := &Client{tracer: otel.Tracer(tracerScope)}
+	c.lb = newBalancer(addr, bc, pc, dial)
+	// Every engine RPC is a pure computation and safe to repeat, but MatMul
+	// is the expensive one: retry it at most once.
+	matMul := rc.Retry
+	matMul.Attempts = min(matMul.Attempts, 2)
+	c.res = resilience.New("engine", rc, map[string]resilience.RetryPolicy{"MatMul": matMul})
+	return c
+}
+
+// Close stops resolution and health checks and releases pooled connections.
+func (c *Client) Close() error {
+	c.lb.close()
+	return nil
+}
+
+// PoolStats reports the balancer policy and per-endpoint pool counters.
+func (c *Client) PoolStats() BalancerStats { return c.lb.stats() }
 
-func (c *Client) dial() (thrift.TTransport, *eng.EngineServiceClient, error) {
+// Ping checks that some engine endpoint answers Hello, bypassing retries and
+// the circuit breaker.
+func (c *Client) Ping(ctx context.Context) error {
+	return rpcerr.FromThrift("engine", c.lb.ping(ctx))
+}
+
+// Breaker exposes the circuit breaker guarding the engine.
+func (c *Client) Breaker() *resilience.Breaker { return c.res.Breaker() }
+
+func dial(addr string) (*conn, error) {
 	tf := thrift.NewTBufferedTransportFactory(8192)
 	pf := thrift.NewTBinaryProtocolFactoryConf(nil)
 	cfg := &thrift.TConfiguration{
@@ -22,65 +59,93 @@ func (c *Client) dial() (thrift.TTransport, *eng.EngineServiceClient, error) {
 		SocketTimeout:  3 * time.Second,
 	}
 
-	sock := thrift.NewTSocketConf(c.addr, cfg)
+	sock := thrift.NewTSocketConf(addr, cfg)
 	if sock == nil {
-		return nil, nil, thrift.NewTTransportException(thrift.NOT_OPEN, "failed to create socket")
+		return nil, thrift.NewTTransportException(thrift.NOT_OPEN, "failed to create socket")
 	}
 	transport, err := tf.GetTransport(sock)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if err := transport.Open(); err != nil {
 		transport.Close()
-		return nil, nil, err
+		return nil, err
 	}
 	iprot := pf.GetProtocol(transport)
-	oprot := pf.GetProtocol(transport)
+	oprot := &traceProtocol{TProtocol: pf.GetProtocol(transport)}
 	tclient := thrift.NewTStandardClient(iprot, oprot)
 	cli := eng.NewEngineServiceClient(tclient)
-	return transport, cli, nil
+	return &conn{trans: transport, cli: cli}, nil
 }
 
-func (c *Client) hello(ctx context.Context, name string) (string, error) {
-	transport, cli, err := c.dial()
-	if err != nil {
-		return "", err
-	}
-	defer transport.Close()
+// call runs fn on a pooled connection to a balancer-picked endpoint, under
+// the retry policy of method and the engine's circuit breaker. Each attempt
+// picks again, so retries move away from a failing replica, and gets its own
+// client span. Errors come back classified (see rpcerr).
+func (c *Client) call(ctx context.Context, method string, fn func(ctx context.Context, cli *eng.EngineServiceClient) error) error {
+	return c.res.Do(ctx, method, func(ctx context.Context) (err error) {
+		ctx, span := c.tracer.Start(ctx, "EngineService/"+method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.RPCSystemKey.String("thrift"),
+				semconv.RPCService("EngineService"),
+				semconv.RPCMethod(method),
+			))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, rpcerr.KindOf(err).String())
+			}
+			span.End()
+		}()
+
+		ep, err := c.lb.pick()
+		if err != nil {
+			return rpcerr.FromThrift("engine", thrift.NewTTransportException(thrift.NOT_OPEN, err.Error()))
+		}
+		span.SetAttributes(semconv.ServerAddress(ep.addr))
+		ep.outstanding.Add(1)
+		defer ep.outstanding.Add(-1)
 
-	resp, err := (*cli).Hello(ctx, &eng.HelloRequest{Name: name})
+		err = ep.pool.do(ctx, func(cn *conn) error { return fn(ctx, cn.cli) })
+		err = rpcerr.FromThrift("engine", err)
+		c.lb.observe(ep, err)
+		return err
+	})
+}
+
+func (c *Client) hello(ctx context.Context, name string) (string, error) {
+	var resp *eng.HelloReply
+	err := c.call(ctx, "Hello", func(ctx context.Context, cli *eng.EngineServiceClient) (err error) {
+		resp, err = cli.Hello(ctx, &eng.HelloRequest{Name: name})
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
 	return resp.GetMessage(), nil
 }
 
-func (c *Client) estimatePi(ctx context.Context, samples int64) (*eng.PiReply, error) {
-	transport, cli, err := c.dial()
-	if err != nil {
-		return nil, err
-	}
-	defer transport.Close()
-
-	return (*cli).EstimatePi(ctx, &eng.PiRequest{Samples: samples})
+func (c *Client) estimatePi(ctx context.Context, samples int64) (resp *eng.PiReply, err error) {
+	err = c.call(ctx, "EstimatePi", func(ctx context.Context, cli *eng.EngineServiceClient) (err error) {
+		resp, err = cli.EstimatePi(ctx, &eng.PiRequest{Samples: samples})
+		return err
+	})
+	return resp, err
 }
 
-func (c *Client) matMul(ctx context.Context, a, b *eng.Matrix) (*eng.MatReply, error) {
-	transport, cli, err := c.dial()
-	if err != nil {
-		return nil, err
-	}
-	defer transport.Close()
-
-	return (*cli).MatMul(ctx, &eng.MatMulRequest{A: a, B: b})
+func (c *Client) matMul(ctx context.Context, a, b *eng.Matrix) (resp *eng.MatReply, err error) {
+	err = c.call(ctx, "MatMul", func(ctx context.Context, cli *eng.EngineServiceClient) (err error) {
+		resp, err = cli.MatMul(ctx, &eng.MatMulRequest{A: a, B: b})
+		return err
+	})
+	return resp, err
 }
 
-func (c *Client) computeStats(ctx context.Context, data []float64, sample bool) (*eng.VectorStatsReply, error) {
-	transport, cli, err := c.dial()
-	if err != nil {
-		return nil, err
-	}
-	defer transport.Close()
-
-	return (*cli).ComputeStats(ctx, &eng.VectorStatsRequest{Data: data, Sample: sample})
+func (c *Client) computeStats(ctx context.Context, data []float64, sample bool) (resp *eng.VectorStatsReply, err error) {
+	err = c.call(ctx, "ComputeStats", func(ctx context.Context, cli *eng.EngineServiceClient) (err error) {
+		resp, err = cli.ComputeStats(ctx, &eng.VectorStatsRequest{Data: data, Sample: sample})
+		return err
+	})
+	return resp, err
 }
@@ -0,0 +1,34 @@
+package engine
+
+import "github.com/Patrick8894/harmonia/api-gw/internal/ratelimit"
+
+// Cost estimates the work a request asks of the engine, in compute units.
+// Rate-limit quotas and admission control both use it.
+func (d PiDTO) Cost() float64 { return float64(d.Samples) }
+
+func (d MatMulDTO) Cost() float64 {
+	return float64(d.A.Rows) * float64(d.A.Cols) * float64(d.B.Cols)
+}
+
+func (d StatsDTO) Cost() float64 { return float64(len(d.Data)) }
+
+// CostRoutes prices engine requests for the compute quota, keyed by
+// ratelimit.RouteName.
+func CostRoutes() map[string]ratelimit.CostFunc {
+	return map[string]ratelimit.CostFunc{
+		"engine:pi":     ratelimit.BodyCost(PiDTO.Cost),
+		"engine:matmul": ratelimit.BodyCost(MatMulDTO.Cost),
+		"engine:stats":  ratelimit.BodyCost(StatsDTO.Cost),
+		// batch routes cost the sum of their items
+		"engine:matmul:batch": ratelimit.BodyCost(sumCost[MatMulDTO]),
+		"engine:stats:batch":  ratelimit.BodyCost(sumCost[StatsDTO]),
+	}
+}
+
+func sumCost[T interface{ Cost() float64 }](items []T) float64 {
+	total := 0.0
+	for _, it := range items {
+		total += it.Cost()
+	}
+	return total
+}
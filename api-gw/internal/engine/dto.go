@@ -1,9 +1,13 @@
 package engine
 
+import "errors"
+
 type PiDTO struct {
 	Samples int64 `json:"samples" binding:"required,min=1"`
 }
 
+// A matrix (or a stats input's data) may instead be sent as
+// {"dataset_id": "..."}; dataset.Store.Resolve inlines it before binding.
 type MatrixDTO struct {
 	Rows int32     `json:"rows" binding:"required,min=1"`
 	Cols int32     `json:"cols" binding:"required,min=1"`
@@ -15,6 +19,19 @@ type MatMulDTO struct {
 	B MatrixDTO `json:"b" binding:"required"`
 }
 
+// Validate checks what binding tags cannot: that A and B can be multiplied
+// and that each matrix's data matches its shape.
+func (d MatMulDTO) Validate() error {
+	if d.A.Cols != d.B.Rows {
+		return errors.New("dimension mismatch: A.cols must equal B.rows")
+	}
+	if int64(d.A.Rows)*int64(d.A.Cols) != int64(len(d.A.Data)) ||
+		int64(d.B.Rows)*int64(d.B.Cols) != int64(len(d.B.Data)) {
+		return errors.New("data length must equal rows*cols for A and B")
+	}
+	return nil
+}
+
 type StatsDTO struct {
 	Data   []float64 `json:"data" binding:"required"`
 	Sample *bool     `json:"sample"` // optional; default to true if nil
@@ -6,13 +6,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/batch"
+	"github.com/Patrick8894/harmonia/api-gw/internal/httpcache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
 )
 
 type Controller struct {
-	svc *Service
+	svc   *Service
+	batch batch.Config
 }
 
-func NewController(svc *Service) *Controller { return &Controller{svc: svc} }
+func NewController(svc *Service, bc batch.Config) *Controller {
+	return &Controller{svc: svc, batch: bc}
+}
 
 func Register(rg *gin.RouterGroup, ctrl *Controller) {
 	g := rg.Group("/engine")
@@ -20,6 +28,9 @@ func Register(rg *gin.RouterGroup, ctrl *Controller) {
 	g.POST("/pi", ctrl.Pi)
 	g.POST("/matmul", ctrl.MatMul)
 	g.POST("/stats", ctrl.Stats)
+	g.POST("/matmul"+batch.Suffix, batch.Only(ctrl.MatMulBatch))
+	g.POST("/stats"+batch.Suffix, batch.Only(ctrl.StatsBatch))
+	g.GET("/pool", ctrl.Pool)
 }
 
 // HelloEngineRPC godoc
@@ -29,7 +40,9 @@ func Register(rg *gin.RouterGroup, ctrl *Controller) {
 // @Produce      json
 // @Param        name  query  string  false  "Name to greet"  default(World)
 // @Success      200   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      502   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Failure      504   {object}  map[string]string
 // @Router       /engine/hello [get]
 func (c *Controller) Hello(ctx *gin.Context) {
 	name := ctx.DefaultQuery("name", "World")
@@ -38,7 +51,7 @@ func (c *Controller) Hello(ctx *gin.Context) {
 
 	msg, err := c.svc.Hello(reqCtx, name)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": msg})
@@ -51,31 +64,31 @@ func (c *Controller) Hello(ctx *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        payload  body  PiDTO  true  "Pi input"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200      {object}  map[string]any
+// @Success      304      "Not modified (If-None-Match matched the ETag)"
 // @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      422      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Failure      504      {object}  map[string]string
 // @Router       /engine/pi [post]
 func (c *Controller) Pi(ctx *gin.Context) {
 	var req PiDTO
 	if err := ctx.ShouldBindJSON(&req); err != nil || req.Samples <= 0 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
 		return
 	}
 	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 4*time.Second)
 	defer cancel()
 
-	resp, cached, err := c.svc.EstimatePi(reqCtx, req.Samples)
+	resp, meta, err := c.svc.EstimatePi(reqCtx, req.Samples)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"pi":     resp.GetPi(),
-		"inside": resp.GetInside(),
-		"total":  resp.GetTotal(),
-		"seed":   resp.GetSeed(),
-		"cached": cached,
-	})
+	httpcache.JSON(ctx, meta, httpcache.WithMeta(piResult(resp), meta))
 }
 
 // MatMul godoc
@@ -85,44 +98,38 @@ func (c *Controller) Pi(ctx *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        payload  body  MatMulDTO  true  "A and B matrices"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200      {object}  map[string]any
+// @Success      304      "Not modified (If-None-Match matched the ETag)"
 // @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Failure      422      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Failure      504      {object}  map[string]string
 // @Router       /engine/matmul [post]
 func (c *Controller) MatMul(ctx *gin.Context) {
 	var req MatMulDTO
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
 		return
 	}
 	// basic validation before RPC
-	if req.A.Cols != req.B.Rows {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "dimension mismatch: A.cols must equal B.rows"})
-		return
-	}
-	if int64(req.A.Rows)*int64(req.A.Cols) != int64(len(req.A.Data)) ||
-		int64(req.B.Rows)*int64(req.B.Cols) != int64(len(req.B.Data)) {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "data length must equal rows*cols for A and B"})
+	if err := req.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
 		return
 	}
 
 	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 6*time.Second)
 	defer cancel()
 
-	resp, cached, err := c.svc.MatMul(reqCtx, req)
+	resp, meta, err := c.svc.MatMul(reqCtx, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
 		return
 	}
-	C := resp.GetC()
-	ctx.JSON(http.StatusOK, gin.H{
-		"c": gin.H{
-			"rows": C.GetRows(),
-			"cols": C.GetCols(),
-			"data": C.GetData(),
-		},
-		"cached": cached,
-	})
+	httpcache.JSON(ctx, meta, httpcache.WithMeta(matMulResult(resp), meta))
 }
 
 // Stats godoc
@@ -132,14 +139,20 @@ func (c *Controller) MatMul(ctx *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        payload  body  StatsDTO  true  "Stats input"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200      {object}  map[string]any
+// @Success      304      "Not modified (If-None-Match matched the ETag)"
 // @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Failure      504      {object}  map[string]string
 // @Router       /engine/stats [post]
 func (c *Controller) Stats(ctx *gin.Context) {
 	var req StatsDTO
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
 		return
 	}
 	sample := true
@@ -149,19 +162,95 @@ func (c *Controller) Stats(ctx *gin.Context) {
 	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	resp, cached, err := c.svc.ComputeStats(reqCtx, StatsDTO{Data: req.Data, Sample: &sample})
+	resp, meta, err := c.svc.ComputeStats(reqCtx, StatsDTO{Data: req.Data, Sample: &sample})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
+		return
+	}
+	httpcache.JSON(ctx, meta, httpcache.WithMeta(statsResult(resp), meta))
+}
+
+// MatMulBatch godoc
+// @Summary      Matrix multiply, batched
+// @Description  Runs MatMul for each pair in the array; cache hits are fetched in one lookup and misses fan out with bounded concurrency. Items come back in order with the status the single route would have used.
+// @Tags         engine
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  []MatMulDTO  true  "Array of A/B pairs"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Success      200      {object}  map[string]any
+// @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Router       /engine/matmul:batch [post]
+func (c *Controller) MatMulBatch(ctx *gin.Context) {
+	var req []MatMulDTO
+	if !batch.Bind(ctx, c.batch, &req) {
+		return
+	}
+	out := make([]batch.Item, len(req))
+	valid := make([]MatMulDTO, 0, len(req))
+	idx := make([]int, 0, len(req))
+	for i, in := range req {
+		if err := in.Validate(); err != nil {
+			out[i] = batch.Invalid(err)
+			continue
+		}
+		valid = append(valid, in)
+		idx = append(idx, i)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 20*time.Second)
+	defer cancel()
+
+	for j, it := range c.svc.MatMulBatch(reqCtx, valid, c.batch.Concurrency) {
+		if it.Err != nil {
+			out[idx[j]] = batch.Failed(it.Err)
+		} else {
+			out[idx[j]] = batch.OK(httpcache.WithMeta(matMulResult(it.Value), it.Meta))
+		}
+	}
+	batch.Respond(ctx, out)
+}
+
+// StatsBatch godoc
+// @Summary      Compute vector statistics, batched
+// @Description  Runs ComputeStats for each dataset in the array; cache hits are fetched in one lookup and misses fan out with bounded concurrency. Items come back in order with the status the single route would have used.
+// @Tags         engine
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  []StatsDTO  true  "Array of stats inputs"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Success      200      {object}  map[string]any
+// @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Router       /engine/stats:batch [post]
+func (c *Controller) StatsBatch(ctx *gin.Context) {
+	var req []StatsDTO
+	if !batch.Bind(ctx, c.batch, &req) {
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"count":    resp.GetCount(),
-		"sum":      resp.GetSum(),
-		"mean":     resp.GetMean(),
-		"variance": resp.GetVariance(),
-		"stddev":   resp.GetStddev(),
-		"min":      resp.GetMin(),
-		"max":      resp.GetMax(),
-		"cached":   cached,
-	})
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	items := c.svc.ComputeStatsBatch(reqCtx, req, c.batch.Concurrency)
+	out := make([]batch.Item, len(items))
+	for i, it := range items {
+		if it.Err != nil {
+			out[i] = batch.Failed(it.Err)
+		} else {
+			out[i] = batch.OK(httpcache.WithMeta(statsResult(it.Value), it.Meta))
+		}
+	}
+	batch.Respond(ctx, out)
+}
+
+// Pool godoc
+// @Summary      Engine endpoints and connection pools
+// @Description  Reports the balancing policy and, per engine endpoint, its health, outstanding calls and Thrift pool counters
+// @Tags         engine
+// @Produce      json
+// @Success      200  {object}  BalancerStats
+// @Router       /engine/pool [get]
+func (c *Controller) Pool(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.svc.PoolStats())
 }
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/httpcache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/jobs"
+)
+
+// JobOps exposes the engine operations to jobs and pipelines, keyed by the
+// route names ratelimit.RouteName gives their HTTP routes (which the job
+// cost table also uses). Inputs are the route bodies and results the route
+// responses, cache fields included.
+// Large π estimates run in batches and report the running estimate.
+func (s *Service) JobOps() map[string]jobs.Op {
+	return map[string]jobs.Op{
+		"engine:pi": jobs.Func(func(ctx context.Context, in PiDTO) (any, error) {
+			return s.estimatePiProgressive(ctx, in.Samples, func(partial gin.H) {
+				jobs.Report(ctx, "progress", partial)
+			})
+		}),
+		"engine:matmul": jobs.Func(func(ctx context.Context, in MatMulDTO) (any, error) {
+			resp, meta, err := s.MatMul(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return httpcache.WithMeta(matMulResult(resp), meta), nil
+		}),
+		"engine:stats": jobs.Func(func(ctx context.Context, in StatsDTO) (any, error) {
+			resp, meta, err := s.ComputeStats(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return httpcache.WithMeta(statsResult(resp), meta), nil
+		}),
+	}
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+var errPoolClosed = errors.New("engine: connection pool closed")
+
+// PoolConfig bounds the Thrift connection pool kept by Client.
+type PoolConfig struct {
+	MaxIdle     int           // connections kept open between calls
+	MaxOpen     int           // cap on connections checked out at once; idle ones are bounded by MaxIdle
+	IdleTimeout time.Duration // idle connections older than this are closed on checkout
+}
+
+// PoolStats is a point-in-time snapshot of the pool counters.
+type PoolStats struct {
+	Open      int   `json:"open"`
+	Idle      int   `json:"idle"`
+	InUse     int   `json:"in_use"`
+	MaxOpen   int   `json:"max_open"`
+	MaxIdle   int   `json:"max_idle"`
+	Dials     int64 `json:"dials"`
+	Reuses    int64 `json:"reuses"`
+	Waits     int64 `json:"waits"`
+	Evictions int64 `json:"evictions"`
+}
+
+type conn struct {
+	trans    thrift.TTransport
+	cli      *eng.EngineServiceClient
+	lastUsed time.Time
+	reused   bool // handed out from the idle list rather than freshly dialed
+}
+
+type pool struct {
+	dial func() (*conn, error)
+	cfg  PoolConfig
+
+	sem chan struct{} // one token per checked-out connection
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+
+	dials, reuses, waits, evictions atomic.Int64
+}
+
+func newPool(cfg PoolConfig, dial func() (*conn, error)) *pool {
+	if cfg.MaxOpen <= 0 {
+		cfg.MaxOpen = 16
+	}
+	if cfg.MaxIdle < 0 {
+		cfg.MaxIdle = 0
+	}
+	if cfg.MaxIdle > cfg.MaxOpen {
+		cfg.MaxIdle = cfg.MaxOpen
+	}
+	return &pool{dial: dial, cfg: cfg, sem: make(chan struct{}, cfg.MaxOpen)}
+}
+
+// get returns an idle connection or dials a new one, waiting for a free slot
+// when MaxOpen connections are already checked out.
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	return p.checkout(ctx, true)
+}
+
+// do runs fn on a pooled connection and hands it back. An idle connection
+// the server has since dropped (restart, its own idle timeout) only shows up
+// as a failed call, so a call that fails that way on a reused connection is
+// replayed once on a freshly dialed one; engine RPCs are pure computations,
+// safe to repeat.
+func (p *pool) do(ctx context.Context, fn func(*conn) error) error {
+	c, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	// read before put: once back on the idle list c belongs to whoever
+	// checks it out next
+	reused := c.reused
+	err = fn(c)
+	p.put(c, err)
+	if !reused || !isStale(err) || ctx.Err() != nil {
+		return err
+	}
+	if c, err = p.checkout(ctx, false); err != nil {
+		return err
+	}
+	err = fn(c)
+	p.put(c, err)
+	return err
+}
+
+func (p *pool) checkout(ctx context.Context, reuse bool) (*conn, error) {
+	select {
+	case p.sem <- struct{}{}:
+	default:
+		p.waits.Add(1)
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.sem
+		return nil, errPoolClosed
+	}
+	for n := len(p.idle); reuse && n > 0; n = len(p.idle) {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		if p.cfg.IdleTimeout > 0 && time.Since(c.lastUsed) > p.cfg.IdleTimeout {
+			p.evictions.Add(1)
+			_ = c.trans.Close()
+			continue
+		}
+		p.mu.Unlock()
+		p.reuses.Add(1)
+		c.reused = true
+		return c, nil
+	}
+	p.mu.Unlock()
+
+	c, err := p.dial()
+	if err != nil {
+		<-p.sem
+		return nil, err
+	}
+	p.dials.Add(1)
+	return c, nil
+}
+
+// put hands a connection back after an RPC. Connections that saw a transport
+// or protocol failure are closed instead of being reused.
+func (p *pool) put(c *conn, rpcErr error) {
+	defer func() { <-p.sem }()
+
+	if isBroken(rpcErr) || !c.trans.IsOpen() {
+		p.evictions.Add(1)
+		_ = c.trans.Close()
+		return
+	}
+	c.lastUsed = time.Now()
+
+	p.mu.Lock()
+	if p.closed || len(p.idle) >= p.cfg.MaxIdle {
+		p.mu.Unlock()
+		_ = c.trans.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+}
+
+func (p *pool) stats() PoolStats {
+	p.mu.Lock()
+	idle := len(p.idle)
+	p.mu.Unlock()
+	inUse := len(p.sem)
+	return PoolStats{
+		Open:      idle + inUse,
+		Idle:      idle,
+		InUse:     inUse,
+		MaxOpen:   p.cfg.MaxOpen,
+		MaxIdle:   p.cfg.MaxIdle,
+		Dials:     p.dials.Load(),
+		Reuses:    p.reuses.Load(),
+		Waits:     p.waits.Load(),
+		Evictions: p.evictions.Load(),
+	}
+}
+
+// close drops all idle connections; checked-out ones are closed on put.
+func (p *pool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+	for _, c := range idle {
+		_ = c.trans.Close()
+	}
+}
+
+// isBroken reports whether err leaves the connection in an unknown state.
+// Application exceptions are complete replies from the server, so the
+// transport stays usable; anything else (I/O, protocol, deadline) does not.
+func isBroken(err error) bool {
+	if err == nil {
+		return false
+	}
+	var appErr thrift.TApplicationException
+	return !errors.As(err, &appErr)
+}
+
+// isStale reports whether err is what a call on a connection the peer had
+// already closed fails with: EOF, reset or broken pipe. Timeouts are not
+// stale; the server may still be working on the request.
+func isStale(err error) bool {
+	if err == nil {
+		return false
+	}
+	var te thrift.TTransportException
+	if errors.As(err, &te) && (te.TypeId() == thrift.END_OF_FILE || te.TypeId() == thrift.NOT_OPEN) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+
+	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
+	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/httpcache"
+)
+
+// A progressive π estimate splits the samples into batches of at least
+// minPiBatch, at most maxPiBatches of them, run piFanout at a time.
+const (
+	minPiBatch   = 1_000_000
+	maxPiBatches = 16
+	piFanout     = 4
+)
+
+// estimatePiProgressive runs EstimatePi over batches and calls report with
+// the pooled estimate each time a batch completes. Batches bypass the
+// result cache: identical batch sizes would otherwise share one sample.
+// Small requests take the ordinary cached path in one go. Either way the
+// result has the fields of the /engine/pi response; for batched runs seed is
+// the first batch's and seeds lists them all, in batch order.
+func (s *Service) estimatePiProgressive(ctx context.Context, samples int64, report func(gin.H)) (gin.H, error) {
+	n := min(samples/minPiBatch, maxPiBatches)
+	if n <= 1 {
+		resp, meta, err := s.EstimatePi(ctx, samples)
+		if err != nil {
+			return nil, err
+		}
+		return httpcache.WithMeta(piResult(resp), meta), nil
+	}
+	if err := s.adm.checkPi(PiDTO{Samples: samples}); err != nil {
+		return nil, err
+	}
+
+	var (
+		mu            sync.Mutex
+		inside, total int64
+		done          int64
+		seeds         = make([]int64, n)
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(piFanout)
+	for i := range n {
+		size := samples / n
+		if i < samples%n {
+			size++
+		}
+		g.Go(func() error {
+			resp, err := run(gctx, s.adm, float64(size), func(ctx context.Context) (*eng.PiReply, error) {
+				return s.c.estimatePi(ctx, size)
+			})
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			inside += resp.GetInside()
+			total += resp.GetTotal()
+			seeds[i] = resp.GetSeed()
+			done++
+			report(gin.H{"pi": pooledPi(inside, total), "inside": inside, "total": total, "batches": done, "of": n})
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	out := httpcache.WithMeta(piResult(&eng.PiReply{Pi: pooledPi(inside, total), Inside: inside, Total: total, Seed: seeds[0]}), cache.Meta{})
+	out["seeds"], out["batches"] = seeds, n
+	return out, nil
+}
+
+func pooledPi(inside, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 4 * float64(inside) / float64(total)
+}
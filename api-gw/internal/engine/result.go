@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"github.com/gin-gonic/gin"
+
+	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
+)
+
+// Result bodies shared by the synchronous handlers and background jobs.
+
+func piResult(r *eng.PiReply) gin.H {
+	return gin.H{
+		"pi":     r.GetPi(),
+		"inside": r.GetInside(),
+		"total":  r.GetTotal(),
+		"seed":   r.GetSeed(),
+	}
+}
+
+func matMulResult(r *eng.MatReply) gin.H {
+	C := r.GetC()
+	return gin.H{
+		"c": gin.H{
+			"rows": C.GetRows(),
+			"cols": C.GetCols(),
+			"data": C.GetData(),
+		},
+	}
+}
+
+func statsResult(r *eng.VectorStatsReply) gin.H {
+	return gin.H{
+		"count":    r.GetCount(),
+		"sum":      r.GetSum(),
+		"mean":     r.GetMean(),
+		"variance": r.GetVariance(),
+		"stddev":   r.GetStddev(),
+		"min":      r.GetMin(),
+		"max":      r.GetMax(),
+	}
+}
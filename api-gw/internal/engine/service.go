@@ -2,70 +2,118 @@ package engine
 
 import (
 	"context"
-	"time"
 
 	eng "github.com/Patrick8894/harmonia/api-gw/gen/engine"
 	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
 )
 
 type Service struct {
 	c   *Client
-	kvs cache.Store
-	ttl time.Duration
+	ld  *cache.Loader
+	adm *admission
 }
 
-func NewService(c *Client, kvs cache.Store, ttl time.Duration) *Service {
-	return &Service{c: c, kvs: kvs, ttl: ttl}
+func NewService(c *Client, ld *cache.Loader, ac AdmissionConfig) *Service {
+	return &Service{c: c, ld: ld, adm: newAdmission(ac)}
 }
 
 func (s *Service) Hello(ctx context.Context, name string) (string, error) {
 	return s.c.hello(ctx, name)
 }
 
-func (s *Service) EstimatePi(ctx context.Context, samples int64) (*eng.PiReply, bool, error) {
-	key := cache.Key("engine:pi", struct{ Samples int64 }{samples})
-	var cached eng.PiReply
-	if ok, _ := s.kvs.Get(ctx, key, &cached); ok {
-		return &cached, true, nil
-	}
-	resp, err := s.c.estimatePi(ctx, samples)
-	if err != nil {
-		return nil, false, err
-	}
-	_ = s.kvs.Set(ctx, key, resp, s.ttl)
-	return resp, false, nil
+// Ping is the readiness probe for the engine.
+func (s *Service) Ping(ctx context.Context) error {
+	return s.c.Ping(ctx)
 }
 
-func (s *Service) MatMul(ctx context.Context, in MatMulDTO) (*eng.MatReply, bool, error) {
-	key := cache.Key("engine:matmul", in)
-	var cached eng.MatReply
-	if ok, _ := s.kvs.Get(ctx, key, &cached); ok {
-		return &cached, true, nil
+// Breaker exposes the circuit breaker guarding the backend.
+func (s *Service) Breaker() *resilience.Breaker {
+	return s.c.Breaker()
+}
+
+func (s *Service) PoolStats() BalancerStats {
+	return s.c.PoolStats()
+}
+
+func (s *Service) EstimatePi(ctx context.Context, samples int64) (*eng.PiReply, cache.Meta, error) {
+	in := PiDTO{Samples: samples}
+	if err := s.adm.checkPi(in); err != nil {
+		return nil, cache.Meta{}, err
 	}
-	a := &eng.Matrix{Rows: in.A.Rows, Cols: in.A.Cols, Data: in.A.Data}
-	b := &eng.Matrix{Rows: in.B.Rows, Cols: in.B.Cols, Data: in.B.Data}
-	resp, err := s.c.matMul(ctx, a, b)
-	if err != nil {
-		return nil, false, err
+	key := piKey(samples)
+	return cache.Load(ctx, s.ld, key, func(ctx context.Context) (*eng.PiReply, error) {
+		return run(ctx, s.adm, in.Cost(), func(ctx context.Context) (*eng.PiReply, error) {
+			return s.c.estimatePi(ctx, samples)
+		})
+	})
+}
+
+func (s *Service) MatMul(ctx context.Context, in MatMulDTO) (*eng.MatReply, cache.Meta, error) {
+	if err := s.adm.checkMatMul(in); err != nil {
+		return nil, cache.Meta{}, err
 	}
-	_ = s.kvs.Set(ctx, key, resp, s.ttl)
-	return resp, false, nil
+	key := matMulKey(in)
+	return cache.Load(ctx, s.ld, key, func(ctx context.Context) (*eng.MatReply, error) {
+		a := &eng.Matrix{Rows: in.A.Rows, Cols: in.A.Cols, Data: in.A.Data}
+		b := &eng.Matrix{Rows: in.B.Rows, Cols: in.B.Cols, Data: in.B.Data}
+		return run(ctx, s.adm, in.Cost(), func(ctx context.Context) (*eng.MatReply, error) {
+			return s.c.matMul(ctx, a, b)
+		})
+	})
 }
 
-func (s *Service) ComputeStats(ctx context.Context, in StatsDTO) (*eng.VectorStatsReply, bool, error) {
-	key := cache.Key("engine:stats", in)
-	var cached eng.VectorStatsReply
-	if ok, _ := s.kvs.Get(ctx, key, &cached); ok {
-		return &cached, true, nil
+func (s *Service) ComputeStats(ctx context.Context, in StatsDTO) (*eng.VectorStatsReply, cache.Meta, error) {
+	if err := s.adm.checkStats(in); err != nil {
+		return nil, cache.Meta{}, err
 	}
-	sample := true
-	if in.Sample != nil {
-		sample = *in.Sample
+	key := statsKey(in)
+	return cache.Load(ctx, s.ld, key, func(ctx context.Context) (*eng.VectorStatsReply, error) {
+		sample := true
+		if in.Sample != nil {
+			sample = *in.Sample
+		}
+		return run(ctx, s.adm, in.Cost(), func(ctx context.Context) (*eng.VectorStatsReply, error) {
+			return s.c.computeStats(ctx, in.Data, sample)
+		})
+	})
+}
+
+// cacheSchema versions the cached engine replies; bump it whenever a reply
+// struct in engine.thrift changes.
+const cacheSchema = "v1"
+
+func piKey(samples int64) string {
+	return cache.Key("engine:pi", cacheSchema, struct{ Samples int64 }{samples})
+}
+
+func matMulKey(in MatMulDTO) string {
+	return cache.Key("engine:matmul", cacheSchema, in)
+}
+
+func statsKey(in StatsDTO) string {
+	if in.Sample == nil {
+		sample := true
+		in.Sample = &sample
 	}
-	resp, err := s.c.computeStats(ctx, in.Data, sample)
-	if err != nil {
-		return nil, false, err
+	return cache.Key("engine:stats", cacheSchema, in)
+}
+
+// CacheRoutes describes each cached engine route, so a cache entry can be
+// located and decoded from the request body that produced it.
+func CacheRoutes() map[string]cache.RouteSpec {
+	return map[string]cache.RouteSpec{
+		"engine:pi": {
+			Key:   cache.BodyKey(func(in PiDTO) string { return piKey(in.Samples) }),
+			Reply: func() any { return new(eng.PiReply) },
+		},
+		"engine:matmul": {
+			Key:   cache.BodyKey(matMulKey),
+			Reply: func() any { return new(eng.MatReply) },
+		},
+		"engine:stats": {
+			Key:   cache.BodyKey(statsKey),
+			Reply: func() any { return new(eng.VectorStatsReply) },
+		},
 	}
-	_ = s.kvs.Set(ctx, key, resp, s.ttl)
-	return resp, false, nil
 }
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const tracerScope = "github.com/Patrick8894/harmonia/api-gw/internal/engine"
+
+// TraceparentField is the field id under which the W3C traceparent of the
+// calling span is appended to every call's argument struct. Servers that
+// don't know it skip it like any unknown field; a server that wants it can
+// declare a trailing "32767: string traceparent" parameter on its methods.
+const TraceparentField int16 = 32767
+
+// traceProtocol writes the traceparent from the call context into the
+// outermost (argument) struct, just before its field stop.
+type traceProtocol struct {
+	thrift.TProtocol
+	depth int
+}
+
+func (p *traceProtocol) WriteStructBegin(ctx context.Context, name string) error {
+	p.depth++
+	return p.TProtocol.WriteStructBegin(ctx, name)
+}
+
+func (p *traceProtocol) WriteStructEnd(ctx context.Context) error {
+	p.depth--
+	return p.TProtocol.WriteStructEnd(ctx)
+}
+
+func (p *traceProtocol) WriteFieldStop(ctx context.Context) error {
+	if p.depth == 1 {
+		carrier := propagation.MapCarrier{}
+		otel.GetTextMapPropagator().Inject(ctx, carrier)
+		if tp := carrier.Get("traceparent"); tp != "" {
+			if err := p.WriteFieldBegin(ctx, "traceparent", thrift.STRING, TraceparentField); err != nil {
+				return err
+			}
+			if err := p.WriteString(ctx, tp); err != nil {
+				return err
+			}
+			if err := p.WriteFieldEnd(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return p.TProtocol.WriteFieldStop(ctx)
+}
@@ -1,12 +1,23 @@
 package health
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
 
-type Controller struct{}
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
+)
 
-func New() *Controller { return &Controller{} }
+type Controller struct {
+	ready    *Readiness
+	breakers []*resilience.Breaker
+}
+
+func New(ready *Readiness, breakers ...*resilience.Breaker) *Controller {
+	return &Controller{ready: ready, breakers: breakers}
+}
 
-// Register wires endpoints for /healthz.
+// Register wires endpoints for /healthz, /readyz and /status.
 func Register(rg *gin.RouterGroup, c *Controller) {
 	rg.GET("/healthz", c.Health)
+	rg.GET("/readyz", c.Ready)
+	rg.GET("/status", c.Status)
 }
@@ -4,11 +4,13 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
 )
 
 // HealthCheck godoc
-// @Summary      Health check
-// @Description  Liveness/readiness probe endpoint
+// @Summary      Liveness check
+// @Description  Reports that the process is up; does not look at dependencies (see /readyz)
 // @Tags         health
 // @Produce      json
 // @Success      200  {object}  map[string]string
@@ -16,3 +18,35 @@ import (
 func (c *Controller) Health(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
+
+// Ready godoc
+// @Summary      Readiness check
+// @Description  Probes MySQL, Redis, the engine and the logic service (results cached briefly). 503 when a critical dependency is down; a non-critical one only marks the gateway degraded. Why a probe failed is logged, not returned.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  Report
+// @Failure      503  {object}  Report
+// @Router       /readyz [get]
+func (c *Controller) Ready(ctx *gin.Context) {
+	rep := c.ready.Report(ctx.Request.Context())
+	code := http.StatusOK
+	if rep.Status == "not_ready" {
+		code = http.StatusServiceUnavailable
+	}
+	ctx.JSON(code, rep)
+}
+
+// Status godoc
+// @Summary      Backend status
+// @Description  Circuit breaker state for each backend (closed / open / half-open)
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]any
+// @Router       /status [get]
+func (c *Controller) Status(ctx *gin.Context) {
+	out := make([]resilience.BreakerStatus, 0, len(c.breakers))
+	for _, b := range c.breakers {
+		out = append(out, b.Status())
+	}
+	ctx.JSON(http.StatusOK, gin.H{"breakers": out})
+}
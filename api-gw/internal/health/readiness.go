@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check probes one dependency; a nil error means it is usable.
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of one probe. The report is public, so why a
+// probe failed (hosts, ports, driver messages) goes to the log instead.
+type CheckResult struct {
+	Status    string  `json:"status"` // "up" | "down"
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// Report is the body of /readyz.
+type Report struct {
+	Status    string                 `json:"status"` // "ready" | "degraded" | "not_ready"
+	Draining  bool                   `json:"draining,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// ReadinessConfig tunes how often and how long dependencies are probed.
+type ReadinessConfig struct {
+	CacheFor time.Duration // reuse a report this long before probing again
+	Timeout  time.Duration // per-probe deadline
+	Critical []string      // dependencies that must be up; others only degrade
+}
+
+// Readiness probes registered dependencies concurrently and caches the
+// resulting report, so a busy orchestrator doesn't hammer the backends.
+type Readiness struct {
+	cfg      ReadinessConfig
+	critical map[string]bool
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	checks map[string]Check
+	last   *Report
+}
+
+func NewReadiness(cfg ReadinessConfig) *Readiness {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Second
+	}
+	crit := make(map[string]bool, len(cfg.Critical))
+	for _, name := range cfg.Critical {
+		crit[name] = true
+	}
+	return &Readiness{cfg: cfg, critical: crit, checks: make(map[string]Check)}
+}
+
+// Add registers a dependency probe under name.
+func (r *Readiness) Add(name string, fn Check) {
+	r.mu.Lock()
+	r.checks[name] = fn
+	r.last = nil
+	r.mu.Unlock()
+}
+
+// SetDraining makes every later report not_ready without probing; it is
+// flipped on shutdown so traffic moves away before the listener closes.
+func (r *Readiness) SetDraining() { r.draining.Store(true) }
+
+// Report returns the cached report, probing again once it is older than
+// CacheFor. Concurrent callers wait for a single probe round.
+func (r *Readiness) Report(ctx context.Context) Report {
+	if r.draining.Load() {
+		return Report{Status: "not_ready", Draining: true, CheckedAt: time.Now()}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last != nil && time.Since(r.last.CheckedAt) < r.cfg.CacheFor {
+		return *r.last
+	}
+	rep := r.probe(ctx)
+	r.last = &rep
+	return rep
+}
+
+func (r *Readiness) probe(ctx context.Context) Report {
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]CheckResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.cfg.Timeout)
+			defer cancel()
+			start := time.Now()
+			err := r.checks[name](pctx)
+			res := CheckResult{
+				Status:    "up",
+				Critical:  r.critical[name],
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				res.Status = "down"
+				slog.Warn("health: dependency down", "check", name, "critical", res.Critical, "err", err)
+			}
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	rep := Report{Status: "ready", CheckedAt: time.Now(), Checks: make(map[string]CheckResult, len(names))}
+	for i, name := range names {
+		res := results[i]
+		rep.Checks[name] = res
+		if res.Status == "up" {
+			continue
+		}
+		if res.Critical {
+			rep.Status = "not_ready"
+		} else if rep.Status == "ready" {
+			rep.Status = "degraded"
+		}
+	}
+	return rep
+}
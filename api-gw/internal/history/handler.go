@@ -0,0 +1,236 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+)
+
+type Controller struct {
+	store  *Store
+	replay http.Handler
+}
+
+// NewController serves a user's history; re-runs are sent back through
+// replay (the gateway's router), so they are authorised, limited, cached
+// and recorded like the original call.
+func NewController(store *Store, replay http.Handler) *Controller {
+	return &Controller{store: store, replay: replay}
+}
+
+func Register(rg *gin.RouterGroup, ctrl *Controller) {
+	g := rg.Group("/history")
+	g.GET("", ctrl.List)
+	g.DELETE("", ctrl.DeleteMatching)
+	g.GET("/:id", ctrl.Get)
+	g.POST("/:id/rerun", ctrl.Rerun)
+	g.DELETE("/:id", ctrl.Delete)
+}
+
+// List godoc
+// @Summary      List my computation history
+// @Description  The caller's successful engine/logic calls, newest first, without request and result bodies. Pass next_cursor back as cursor for the next page.
+// @Tags         history
+// @Produce      json
+// @Param        op      query  string  false  "Operation, e.g. engine:matmul"
+// @Param        from    query  string  false  "Earliest time, YYYY-MM-DD or RFC 3339 (inclusive)"
+// @Param        to      query  string  false  "Latest time, YYYY-MM-DD (whole day) or RFC 3339 (exclusive)"
+// @Param        cursor  query  string  false  "next_cursor from the previous page"
+// @Param        limit   query  int     false  "Page size"  default(50)
+// @Success      200     {object}  map[string]any
+// @Failure      400     {object}  map[string]string
+// @Router       /history [get]
+func (c *Controller) List(ctx *gin.Context) {
+	f, ok := c.filter(ctx)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	if cur := ctx.Query("cursor"); cur != "" {
+		if f.Before, err = decodeCursor(cur); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+			return
+		}
+	}
+	f.Limit = limit + 1
+
+	list, err := c.store.List(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), f)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list history", "request_id": requestid.Get(ctx)})
+		return
+	}
+	resp := gin.H{"entries": list, "has_more": len(list) > limit}
+	if len(list) > limit {
+		list = list[:limit]
+		resp["entries"] = list
+		resp["next_cursor"] = encodeCursor(list[limit-1].ID)
+	}
+	if list == nil {
+		resp["entries"] = []*Entry{}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Get godoc
+// @Summary      One history entry
+// @Description  The recorded request and result (or a cache key reference for large results)
+// @Tags         history
+// @Produce      json
+// @Param        id   path  int  true  "Entry ID"
+// @Success      200  {object}  Entry
+// @Failure      404  {object}  map[string]string
+// @Router       /history/{id} [get]
+func (c *Controller) Get(ctx *gin.Context) {
+	if e, ok := c.load(ctx); ok {
+		ctx.JSON(http.StatusOK, e)
+	}
+}
+
+// Rerun godoc
+// @Summary      Re-run a past request
+// @Description  Sends the recorded request to its route again and returns that route's response; the new call is recorded as a new entry. Cache-Control is honoured, so "no-cache" forces a fresh computation.
+// @Tags         history
+// @Produce      json
+// @Param        id             path    int     true   "Entry ID"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Success      200  {object}  map[string]any
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /history/{id}/rerun [post]
+func (c *Controller) Rerun(ctx *gin.Context) {
+	e, ok := c.load(ctx)
+	if !ok {
+		return
+	}
+	if !e.CanRerun {
+		reason := "the request was too large to keep, so it cannot be re-run"
+		if clipped(e.Path) {
+			reason = "the request path was too long to keep, so it cannot be re-run"
+		}
+		ctx.JSON(http.StatusConflict, gin.H{"error": reason, "request_id": requestid.Get(ctx)})
+		return
+	}
+	u, err := url.ParseRequestURI(e.Path)
+	if err != nil || !strings.HasPrefix(u.Path, "/api/") {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "recorded path is not replayable", "request_id": requestid.Get(ctx)})
+		return
+	}
+
+	req := ctx.Request.Clone(ctx.Request.Context())
+	req.Method, req.URL, req.RequestURI = e.Method, u, e.Path
+	req.Body = io.NopCloser(bytes.NewReader(e.Request))
+	req.ContentLength = int64(len(e.Request))
+	req.Header.Del("Content-Length")
+	if len(e.Request) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	ctx.Header("X-History-Rerun-Of", strconv.FormatUint(e.ID, 10))
+	c.replay.ServeHTTP(ctx.Writer, req)
+}
+
+// Delete godoc
+// @Summary      Delete a history entry
+// @Tags         history
+// @Produce      json
+// @Param        id   path  int  true  "Entry ID"
+// @Success      200  {object}  map[string]any
+// @Failure      404  {object}  map[string]string
+// @Router       /history/{id} [delete]
+func (c *Controller) Delete(ctx *gin.Context) {
+	id, ok := parseID(ctx)
+	if !ok {
+		return
+	}
+	err := c.store.Delete(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete history entry", "request_id": requestid.Get(ctx)})
+	default:
+		ctx.JSON(http.StatusOK, gin.H{"deleted": id})
+	}
+}
+
+// DeleteMatching godoc
+// @Summary      Delete history entries in bulk
+// @Description  Deletes the caller's entries matching op/from/to; with no filter, all=true is required
+// @Tags         history
+// @Produce      json
+// @Param        op    query  string  false  "Operation, e.g. engine:matmul"
+// @Param        from  query  string  false  "Earliest time, YYYY-MM-DD or RFC 3339 (inclusive)"
+// @Param        to    query  string  false  "Latest time, YYYY-MM-DD (whole day) or RFC 3339 (exclusive)"
+// @Param        all   query  bool    false  "Confirm deleting the whole history"
+// @Success      200   {object}  map[string]any
+// @Failure      400   {object}  map[string]string
+// @Router       /history [delete]
+func (c *Controller) DeleteMatching(ctx *gin.Context) {
+	f, ok := c.filter(ctx)
+	if !ok {
+		return
+	}
+	if all, _ := strconv.ParseBool(ctx.Query("all")); f.empty() && !all {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "give op, from or to, or all=true to delete everything", "request_id": requestid.Get(ctx)})
+		return
+	}
+	n, err := c.store.DeleteMatching(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), f)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete history", "request_id": requestid.Get(ctx)})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+func (c *Controller) filter(ctx *gin.Context) (Filter, bool) {
+	f := Filter{Op: strings.Replace(ctx.Query("op"), ".", ":", 1)}
+	var err error
+	if s := ctx.Query("from"); s != "" {
+		f.From, err = parseTime(s, false)
+	}
+	if s := ctx.Query("to"); s != "" && err == nil {
+		f.To, err = parseTime(s, true)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return f, false
+	}
+	return f, true
+}
+
+func (c *Controller) load(ctx *gin.Context) (*Entry, bool) {
+	id, ok := parseID(ctx)
+	if !ok {
+		return nil, false
+	}
+	e, err := c.store.Get(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return nil, false
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load history entry", "request_id": requestid.Get(ctx)})
+		return nil, false
+	}
+	return e, true
+}
+
+func parseID(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": ErrNotFound.Error(), "request_id": requestid.Get(ctx)})
+		return 0, false
+	}
+	return id, true
+}
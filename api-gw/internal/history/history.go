@@ -0,0 +1,98 @@
+// Package history keeps a per-user record of successful engine and logic
+// calls so they can be browsed, re-run and deleted later.
+package history
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("history entry not found")
+
+// Entry is one recorded call. Request is the body exactly as it was sent;
+// Result is the response body, or empty with ResultRef naming the cache key
+// it was served from when it was too large to keep.
+type Entry struct {
+	ID        uint64          `json:"id"`
+	Op        string          `json:"op"` // route name, e.g. "engine:matmul"
+	Method    string          `json:"method"`
+	Path      string          `json:"path"`
+	Summary   string          `json:"summary"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	ResultRef string          `json:"result_ref,omitempty"`
+	Latency   time.Duration   `json:"-"`
+	LatencyMS float64         `json:"latency_ms"`
+	Cached    bool            `json:"cached"`
+	CanRerun  bool            `json:"rerunnable"` // false when the request body was over the size limit or the path was clipped
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// maxPath is the width of the path column. Request URIs are escaped ASCII,
+// so a trailing clipMark can only come from clipPath.
+const (
+	maxPath  = 512
+	clipMark = "…"
+)
+
+// clipPath fits a request URI into the path column. A clipped path no
+// longer names the request, so such entries can't be re-run.
+func clipPath(uri string) string {
+	if len(uri) <= maxPath {
+		return uri
+	}
+	return uri[:maxPath-len(clipMark)] + clipMark
+}
+
+func clipped(path string) bool { return strings.HasSuffix(path, clipMark) }
+
+// Filter selects a user's entries. Before is a cursor: only entries older
+// than that ID are returned.
+type Filter struct {
+	Op     string
+	From   time.Time // inclusive
+	To     time.Time // exclusive
+	Before uint64
+	Limit  int
+}
+
+func (f Filter) empty() bool {
+	return f.Op == "" && f.From.IsZero() && f.To.IsZero()
+}
+
+// The cursor is opaque to clients; it is the ID of the last entry served.
+func encodeCursor(id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(id, 10)))
+}
+
+func decodeCursor(s string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	return id, nil
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates. A date given as an
+// upper bound covers that whole day.
+func parseTime(s string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date " + strconv.Quote(s) + ": want YYYY-MM-DD or RFC 3339")
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,186 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func RunMigrations(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS history (
+		id          BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+		user_id     BIGINT UNSIGNED NOT NULL,
+		op          VARCHAR(64)  NOT NULL,
+		method      VARCHAR(8)   NOT NULL,
+		path        VARCHAR(512) NOT NULL,
+		summary     VARCHAR(255) NOT NULL,
+		request     LONGBLOB     NULL,
+		result      LONGBLOB     NULL,
+		result_ref  VARCHAR(255) NULL,
+		latency_us  BIGINT       NOT NULL,
+		cached      BOOLEAN      NOT NULL,
+		created_at  DATETIME(3)  NOT NULL,
+		KEY idx_history_user (user_id, id),
+		KEY idx_history_user_op (user_id, op, id),
+		KEY idx_history_created (created_at),
+		CONSTRAINT fk_history_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+		) ENGINE=InnoDB;`)
+	return err
+}
+
+// Store keeps entries in the history table, keyed to users by users.id.
+// Callers pass usernames; the ID is resolved in the same statement.
+type Store struct{ db *sql.DB }
+
+func NewStore(db *sql.DB) *Store { return &Store{db: db} }
+
+const userID = `(SELECT id FROM users WHERE username=?)`
+
+func (s *Store) Insert(ctx context.Context, user string, e *Entry) error {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO history (user_id, op, method, path, summary, request, result, result_ref, latency_us, cached, created_at)
+		 SELECT id, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? FROM users WHERE username=?`,
+		e.Op, e.Method, e.Path, e.Summary, nullBytes(e.Request), nullBytes(e.Result), nullString(e.ResultRef),
+		e.Latency.Microseconds(), e.Cached, e.CreatedAt.UTC(), user)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	e.ID = uint64(id)
+	return err
+}
+
+// List returns up to f.Limit entries, newest first, without request and
+// result bodies.
+func (s *Store) List(ctx context.Context, user string, f Filter) ([]*Entry, error) {
+	where, args := f.where(user)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, op, method, path, summary, NULL, NULL, result_ref, latency_us, cached, created_at, request IS NOT NULL
+		 FROM history WHERE `+where+` ORDER BY id DESC LIMIT ?`, append(args, f.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) Get(ctx context.Context, user string, id uint64) (*Entry, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, op, method, path, summary, request, result, result_ref, latency_us, cached, created_at, request IS NOT NULL
+		 FROM history WHERE id=? AND user_id=`+userID, id, user)
+	e, err := scanEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return e, err
+}
+
+func (s *Store) Delete(ctx context.Context, user string, id uint64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM history WHERE id=? AND user_id=`+userID, id, user)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteMatching deletes the user's entries selected by f, ignoring its
+// cursor and limit.
+func (s *Store) DeleteMatching(ctx context.Context, user string, f Filter) (int64, error) {
+	f.Before = 0
+	where, args := f.where(user)
+	res, err := s.db.ExecContext(ctx, `DELETE FROM history WHERE `+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Purge deletes entries created before the cutoff, batch rows at a time so
+// a large backlog does not hold one long lock.
+func (s *Store) Purge(ctx context.Context, before time.Time, batch int) (int64, error) {
+	var total int64
+	for {
+		res, err := s.db.ExecContext(ctx, `DELETE FROM history WHERE created_at < ? LIMIT ?`, before.UTC(), batch)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		total += n
+		if err != nil || n < int64(batch) {
+			return total, err
+		}
+	}
+}
+
+func (f Filter) where(user string) (string, []any) {
+	conds := []string{"user_id=" + userID}
+	args := []any{user}
+	if f.Op != "" {
+		conds = append(conds, "op=?")
+		args = append(args, f.Op)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, f.To.UTC())
+	}
+	if f.Before > 0 {
+		conds = append(conds, "id < ?")
+		args = append(args, f.Before)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func scanEntry(row interface{ Scan(...any) error }) (*Entry, error) {
+	var (
+		e               Entry
+		request, result []byte
+		ref             sql.NullString
+		latencyUS       int64
+		hasRequest      bool
+	)
+	if err := row.Scan(&e.ID, &e.Op, &e.Method, &e.Path, &e.Summary, &request, &result, &ref,
+		&latencyUS, &e.Cached, &e.CreatedAt, &hasRequest); err != nil {
+		return nil, err
+	}
+	if len(request) > 0 {
+		e.Request = request
+	}
+	if len(result) > 0 {
+		e.Result = result
+	}
+	e.ResultRef = ref.String
+	e.Latency = time.Duration(latencyUS) * time.Microsecond
+	e.LatencyMS = float64(latencyUS) / 1000
+	e.CanRerun = (e.Method == http.MethodGet || hasRequest) && !clipped(e.Path)
+	return &e, nil
+}
+
+func nullBytes(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
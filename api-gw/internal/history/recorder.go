@@ -0,0 +1,319 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/httpcache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/ratelimit"
+)
+
+var (
+	recorded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "harmonia_history_records_total",
+		Help: "History writes by outcome (stored, dropped when the buffer is full, failed).",
+	}, []string{"outcome"})
+	purged = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "harmonia_history_purged_total",
+		Help: "History entries deleted by the retention job.",
+	})
+)
+
+type Config struct {
+	MaxRequestBytes int           // larger request bodies are not kept (the entry can't be re-run)
+	MaxResultBytes  int           // larger results are kept as a cache key reference only
+	Retention       time.Duration // <= 0 keeps entries forever
+	PurgeEvery      time.Duration
+	Buffer          int // entries waiting to be written
+}
+
+type pending struct {
+	user  string
+	entry *Entry
+}
+
+// Recorder writes history entries off the request path: Middleware queues
+// them and one writer goroutine inserts them. It also runs the retention
+// job.
+type Recorder struct {
+	store *Store
+	cfg   Config
+	queue chan pending
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func NewRecorder(store *Store, cfg Config) *Recorder {
+	if cfg.PurgeEvery <= 0 {
+		cfg.PurgeEvery = time.Hour
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = 1024
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Recorder{store: store, cfg: cfg, queue: make(chan pending, cfg.Buffer), ctx: ctx, stop: stop}
+}
+
+func (r *Recorder) Start() {
+	r.wg.Add(2)
+	go r.write()
+	go func() {
+		defer r.wg.Done()
+		if r.cfg.Retention <= 0 {
+			return
+		}
+		r.purge()
+		t := time.NewTicker(r.cfg.PurgeEvery)
+		defer t.Stop()
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-t.C:
+				r.purge()
+			}
+		}
+	}()
+}
+
+// Close stops the retention job and writes whatever is still queued.
+func (r *Recorder) Close() error {
+	r.stop()
+	r.wg.Wait()
+	return nil
+}
+
+func (r *Recorder) write() {
+	defer r.wg.Done()
+	for {
+		select {
+		case p := <-r.queue:
+			r.insert(p)
+		case <-r.ctx.Done():
+			for {
+				select {
+				case p := <-r.queue:
+					r.insert(p)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Recorder) insert(p pending) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.store.Insert(ctx, p.user, p.entry); err != nil {
+		recorded.WithLabelValues("failed").Inc()
+		slog.Warn("history: insert failed", "op", p.entry.Op, "err", err)
+		return
+	}
+	recorded.WithLabelValues("stored").Inc()
+}
+
+func (r *Recorder) purge() {
+	ctx, cancel := context.WithTimeout(r.ctx, time.Minute)
+	defer cancel()
+	n, err := r.store.Purge(ctx, time.Now().Add(-r.cfg.Retention), 5000)
+	purged.Add(float64(n))
+	if err != nil {
+		slog.Warn("history: purge failed", "deleted", n, "err", err)
+	} else if n > 0 {
+		slog.Info("history: purged old entries", "deleted", n)
+	}
+}
+
+// Middleware records every 200 response of the routes below it for the
+// signed-in user, and every 304: a revalidation the cache answered, recorded
+// as cached with the entry it matched as ResultRef. A nil Recorder records
+// nothing.
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	if r == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		user := c.GetString(auth.CtxUserKey)
+		if user == "" {
+			c.Next()
+			return
+		}
+		var body []byte
+		if c.Request.Body != nil && c.Request.Method != http.MethodGet {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				body = nil
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		w := &capture{ResponseWriter: c.Writer, limit: r.cfg.MaxResultBytes}
+		c.Writer = w
+		start := time.Now()
+
+		c.Next()
+
+		status := w.Status()
+		if status != http.StatusOK && status != http.StatusNotModified {
+			return
+		}
+		e := &Entry{
+			Op:        ratelimit.Route(c),
+			Method:    c.Request.Method,
+			Path:      clipPath(c.Request.URL.RequestURI()),
+			Summary:   summarize(body, c.Request.URL.RawQuery),
+			Latency:   time.Since(start),
+			CreatedAt: start,
+		}
+		if len(body) <= r.cfg.MaxRequestBytes {
+			e.Request = body
+		}
+		if !w.over && status == http.StatusOK {
+			e.Result = w.buf.Bytes()
+		}
+		if v, ok := c.Get(httpcache.MetaKey); ok {
+			meta := v.(cache.Meta)
+			e.Cached = meta.Cached || status == http.StatusNotModified
+			if (w.over || status == http.StatusNotModified) && meta.Stored {
+				e.ResultRef = meta.Key
+			}
+		} else if !w.over {
+			e.Cached = allCached(e.Result)
+		}
+
+		select {
+		case r.queue <- pending{user: user, entry: e}:
+		default:
+			recorded.WithLabelValues("dropped").Inc()
+		}
+	}
+}
+
+// allCached reads the summary of a batch response: true when every item was
+// a cache hit.
+func allCached(body []byte) bool {
+	var b struct {
+		Count  int `json:"count"`
+		Cached int `json:"cached"`
+	}
+	return json.Unmarshal(body, &b) == nil && b.Count > 0 && b.Cached == b.Count
+}
+
+// capture keeps a copy of the response body up to limit bytes.
+type capture struct {
+	gin.ResponseWriter
+	buf   bytes.Buffer
+	limit int
+	over  bool
+}
+
+func (w *capture) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capture) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *capture) keep(b []byte) {
+	if w.over {
+		return
+	}
+	if w.buf.Len()+len(b) > w.limit {
+		w.over = true
+		w.buf = bytes.Buffer{}
+		return
+	}
+	w.buf.Write(b)
+}
+
+const summaryLen = 255
+
+// summarize renders a request in one line: scalars as they are, long
+// strings clipped and long arrays as their length, e.g.
+// {a: {cols: 3, data: [6 items], rows: 2}, b: {…}}.
+func summarize(body []byte, query string) string {
+	if len(body) == 0 {
+		return clip(query, summaryLen)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Sprintf("%d bytes", len(body))
+	}
+	var sb strings.Builder
+	render(&sb, v, 0)
+	return clip(sb.String(), summaryLen)
+}
+
+func render(sb *strings.Builder, v any, depth int) {
+	switch x := v.(type) {
+	case map[string]any:
+		if depth > 2 {
+			sb.WriteString("{…}")
+			return
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(k + ": ")
+			render(sb, x[k], depth+1)
+		}
+		sb.WriteByte('}')
+	case []any:
+		if len(x) > 4 || depth > 2 {
+			fmt.Fprintf(sb, "[%d items]", len(x))
+			return
+		}
+		sb.WriteByte('[')
+		for i, it := range x {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			render(sb, it, depth+1)
+		}
+		sb.WriteByte(']')
+	case string:
+		sb.WriteString(fmt.Sprintf("%q", clip(x, 40)))
+	case nil:
+		sb.WriteString("null")
+	default:
+		fmt.Fprint(sb, x)
+	}
+}
+
+func clip(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,122 @@
+// Package httpcache maps HTTP caching semantics (Cache-Control, ETag, Age,
+// If-None-Match) onto the gateway's RPC result cache.
+package httpcache
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/logging"
+)
+
+// Middleware parses the request's Cache-Control (and legacy Pragma) header
+// into cache.Directives on the request context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := parse(c.GetHeader("Cache-Control"))
+		if strings.EqualFold(strings.TrimSpace(c.GetHeader("Pragma")), "no-cache") {
+			d.NoCache = true
+		}
+		if d != (cache.Directives{}) {
+			c.Request = c.Request.WithContext(cache.WithDirectives(c.Request.Context(), d))
+		}
+		c.Next()
+	}
+}
+
+func parse(header string) cache.Directives {
+	var d cache.Directives
+	for _, part := range strings.Split(header, ",") {
+		name, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(name) {
+		case "no-cache":
+			d.NoCache = true
+		case "no-store":
+			d.NoStore = true
+		case "max-age":
+			if n, err := strconv.Atoi(strings.Trim(val, `"`)); err == nil && n >= 0 {
+				d.MaxAge = time.Duration(n) * time.Second
+				d.HasMax = true
+			}
+		}
+	}
+	return d
+}
+
+// MetaKey is the gin context key JSON leaves the result's cache.Meta under,
+// for middleware that runs after the handler.
+const MetaKey = "httpcache.meta"
+
+// JSON writes body with ETag/Cache-Control/Age headers derived from meta, or
+// a bare 304 when the request's If-None-Match already names that ETag.
+func JSON(c *gin.Context, meta cache.Meta, body any) {
+	c.Set(MetaKey, meta)
+	logging.Annotate(c.Request.Context(), slog.String("cache", outcome(meta)))
+	etag := ETag(meta)
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !meta.Stored {
+		c.Header("Cache-Control", "no-store")
+	} else {
+		fresh := max(meta.TTL-meta.Age, 0)
+		c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(fresh.Seconds())))
+		c.Header("Age", strconv.Itoa(int(meta.Age.Seconds())))
+	}
+
+	if etag != "" && matches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+func outcome(meta cache.Meta) string {
+	switch {
+	case meta.Stale:
+		return "stale"
+	case meta.Cached:
+		return "hit"
+	case meta.Shared:
+		return "shared"
+	}
+	return "miss"
+}
+
+// ETag identifies one stored version of a cached result: the request hash
+// from the cache key plus when the result was produced.
+func ETag(meta cache.Meta) string {
+	if meta.Key == "" || meta.StoredAt.IsZero() {
+		return ""
+	}
+	hash := meta.Key[strings.LastIndexByte(meta.Key, ':')+1:]
+	if len(hash) > 16 {
+		hash = hash[:16]
+	}
+	return `"` + hash + "-" + strconv.FormatInt(meta.StoredAt.UnixMilli(), 36) + `"`
+}
+
+func matches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMeta adds the cache fields every RPC result body carries.
+func WithMeta(body gin.H, meta cache.Meta) gin.H {
+	body["cached"] = meta.Cached
+	body["stale"] = meta.Stale
+	body["shared"] = meta.Shared
+	body["age"] = meta.Age.Seconds()
+	return body
+}
@@ -1,15 +1,29 @@
 package httpserver
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
+	"github.com/Patrick8894/harmonia/api-gw/internal/admin"
 	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/batch"
 	"github.com/Patrick8894/harmonia/api-gw/internal/config"
+	"github.com/Patrick8894/harmonia/api-gw/internal/dataset"
 	"github.com/Patrick8894/harmonia/api-gw/internal/engine"
 	"github.com/Patrick8894/harmonia/api-gw/internal/health"
 	"github.com/Patrick8894/harmonia/api-gw/internal/hello"
+	"github.com/Patrick8894/harmonia/api-gw/internal/history"
+	"github.com/Patrick8894/harmonia/api-gw/internal/httpcache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/jobs"
+	"github.com/Patrick8894/harmonia/api-gw/internal/logging"
 	"github.com/Patrick8894/harmonia/api-gw/internal/logic"
+	"github.com/Patrick8894/harmonia/api-gw/internal/pipeline"
+	"github.com/Patrick8894/harmonia/api-gw/internal/ratelimit"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+	"github.com/Patrick8894/harmonia/api-gw/internal/tracing"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -22,27 +36,73 @@ func RegisterRoutes(
 	healthCtrl *health.Controller,
 	helloCtrl *hello.Controller,
 	authCtrl *auth.Controller,
+	adminCtrl *admin.Controller,
+	jobsCtrl *jobs.Controller,
+	pipelineCtrl *pipeline.Controller,
+	historyCtrl *history.Controller,
+	datasetCtrl *dataset.Controller,
 	sessStore auth.SessionStore,
+	limiter *ratelimit.Limiter,
+	recorder *history.Recorder,
+	datasets *dataset.Store,
 ) {
-	// Global auth middleware to parse cookie (non-fatal)
+	// Request ID and access log, request metrics and the server span first so
+	// they cover everything below, then the global auth middleware to parse
+	// cookie (non-fatal)
+	r.Use(requestid.Middleware(), logging.AccessLog(), instrument(), tracing.Middleware())
 	r.Use(auth.Middleware(cfg.CookieName, sessStore))
 
+	// Prometheus scrape endpoint (outside /api). With METRICS_ADDR set it is
+	// served on that listener instead (see main); here only admins see it.
+	if cfg.MetricsAddr == "" {
+		r.GET("/metrics", auth.RequireAuth(cfg.CookieName, sessStore), auth.RequireAdmin(cfg.AdminUsers), gin.WrapH(promhttp.Handler()))
+	}
+
 	api := r.Group("/api")
 
-	// Auth
-	authCtrl.Register(api)
+	// Auth (login/register limited per client IP)
+	authCtrl.Register(api.Group("", limiter.PerIP()))
 
 	// Health + Hello
 	hello.Register(api, helloCtrl)
 	health.Register(api, healthCtrl)
 
-	// Protected feature groups
-	engineParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore))
-	logicParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore))
+	// Protected feature groups, limited per user. Successful calls are
+	// recorded in the caller's history as sent, before dataset references
+	// are inlined, so the limiter and everything after it see the data
+	engineParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore), recorder.Middleware(), datasets.Resolve(), limiter.PerUser(), httpcache.Middleware())
+	logicParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore), recorder.Middleware(), datasets.Resolve(), limiter.PerUser(), httpcache.Middleware())
 
 	// Features
-	engine.Register(engineParent, engine.NewController(engSvc))
-	logic.Register(logicParent, logic.NewController(lgSvc))
+	batchCfg := batch.Config{MaxItems: cfg.BatchMaxItems, Concurrency: cfg.BatchConcurrency}
+	engine.Register(engineParent, engine.NewController(engSvc, batchCfg))
+	logic.Register(logicParent, logic.NewController(lgSvc, batchCfg, logic.Timeouts{
+		Evaluate:  time.Duration(cfg.LogicEvalTimeoutSeconds) * time.Second,
+		Transform: time.Duration(cfg.LogicTransformTimeoutSeconds) * time.Second,
+		Plan:      time.Duration(cfg.LogicPlanTimeoutSeconds) * time.Second,
+		Batch:     time.Duration(cfg.LogicBatchTimeoutSeconds) * time.Second,
+	}))
+
+	// Background jobs (owner-scoped; no HTTP caching)
+	jobsParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore), datasets.Resolve(), limiter.PerUser())
+	jobs.Register(jobsParent, jobsCtrl)
+
+	// Pipelines (each step is cached by the service layer, not per request)
+	pipelineParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore), datasets.Resolve(), limiter.PerUser())
+	pipeline.Register(pipelineParent, pipelineCtrl)
+
+	// Computation history (re-runs go back through the router, so they are
+	// limited there)
+	historyParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore))
+	history.Register(historyParent, historyCtrl)
+
+	// Datasets (uploads count against the per-user limits)
+	datasetParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore), limiter.PerUser())
+	dataset.Register(datasetParent, datasetCtrl)
+
+	// Admin-only operations
+	adminParent := api.Group("", auth.RequireAuth(cfg.CookieName, sessStore), auth.RequireAdmin(cfg.AdminUsers))
+	admin.Register(adminParent, adminCtrl)
 
 	// Swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
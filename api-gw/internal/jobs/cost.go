@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"encoding/json"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/ratelimit"
+)
+
+// SubmitCost charges a job submission what its operation would cost as a
+// synchronous request, so jobs don't sidestep the compute quota.
+func SubmitCost(costs map[string]ratelimit.CostFunc) ratelimit.CostFunc {
+	return func(body []byte) (float64, error) {
+		var req SubmitDTO
+		if err := json.Unmarshal(body, &req); err != nil {
+			return 0, err
+		}
+		if fn, ok := costs[req.Op]; ok {
+			return fn(req.Input)
+		}
+		return 1, nil
+	}
+}
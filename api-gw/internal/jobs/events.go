@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Event is one entry of a job's event stream. IDs increase by one per job,
+// so a client that reconnects with Last-Event-ID gets what it missed.
+type Event struct {
+	ID   int64           `json:"id"`
+	Type string          `json:"type"` // status | progress | task | result
+	Data json.RawMessage `json:"data"`
+}
+
+// hub keeps the recent events of the jobs this gateway has seen and wakes
+// streaming clients when new ones arrive. Streams of finished jobs linger
+// for a while so late or reconnecting clients still get the result.
+type hub struct {
+	keep   int
+	linger time.Duration
+
+	mu      sync.Mutex
+	streams map[string]*stream
+}
+
+type stream struct {
+	events []Event // the newest keep events
+	next   int64
+	done   bool
+	doneAt time.Time
+	subs   map[chan struct{}]struct{}
+}
+
+func newHub(keep int, linger time.Duration) *hub {
+	return &hub{keep: keep, linger: linger, streams: make(map[string]*stream)}
+}
+
+func (h *hub) publish(id, typ string, data any) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.streams[id]
+	if s == nil {
+		s = &stream{next: 1, subs: make(map[chan struct{}]struct{})}
+		h.streams[id] = s
+	}
+	if s.done {
+		return
+	}
+	s.events = append(s.events, Event{ID: s.next, Type: typ, Data: b})
+	s.next++
+	if len(s.events) > h.keep {
+		s.events = s.events[len(s.events)-h.keep:]
+	}
+	wake(s)
+}
+
+// finish publishes the final event and closes the stream.
+func (h *hub) finish(id, typ string, data any) {
+	h.publish(id, typ, data)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s := h.streams[id]; s != nil && !s.done {
+		s.done, s.doneAt = true, time.Now()
+		wake(s)
+	}
+}
+
+func wake(s *stream) {
+	for ch := range s.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// subscribe registers for wake-ups on id's stream; ok is false when this
+// gateway has no stream for the job.
+func (h *hub) subscribe(id string) (ch chan struct{}, unsubscribe func(), ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.streams[id]
+	if s == nil {
+		return nil, nil, false
+	}
+	ch = make(chan struct{}, 1)
+	s.subs[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		delete(s.subs, ch)
+		h.mu.Unlock()
+	}, true
+}
+
+// since returns the retained events after the given ID and whether the
+// stream has ended. An ID from a previous incarnation of the stream (after
+// a restart) replays everything.
+func (h *hub) since(id string, after int64) ([]Event, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.streams[id]
+	if s == nil {
+		return nil, true
+	}
+	if after >= s.next {
+		after = 0
+	}
+	var out []Event
+	for _, e := range s.events {
+		if e.ID > after {
+			out = append(out, e)
+		}
+	}
+	return out, s.done
+}
+
+func (h *hub) sweep() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, s := range h.streams {
+		if s.done && len(s.subs) == 0 && time.Since(s.doneAt) > h.linger {
+			delete(h.streams, id)
+		}
+	}
+}
+
+type reporterKey struct{}
+
+// Report publishes an intermediate event (e.g. "progress" with a partial
+// result) for the job running under ctx. Outside a job it does nothing.
+func Report(ctx context.Context, typ string, data any) {
+	if fn, ok := ctx.Value(reporterKey{}).(func(string, any)); ok {
+		fn(typ, data)
+	}
+}
@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+type Controller struct {
+	mgr *Manager
+
+	closing chan struct{} // closed by Shutdown; ends open event streams
+	once    sync.Once
+}
+
+func NewController(mgr *Manager) *Controller {
+	return &Controller{mgr: mgr, closing: make(chan struct{})}
+}
+
+// Shutdown ends open event streams so they don't hold up the server's drain;
+// clients reconnect with Last-Event-ID and resume. Register it with
+// http.Server.RegisterOnShutdown.
+func (c *Controller) Shutdown() { c.once.Do(func() { close(c.closing) }) }
+
+func Register(rg *gin.RouterGroup, ctrl *Controller) {
+	g := rg.Group("/jobs")
+	g.POST("", ctrl.Submit)
+	g.GET("", ctrl.List)
+	g.GET("/:id", ctrl.Get)
+	g.GET("/:id/result", ctrl.Result)
+	g.GET("/:id/events", ctrl.Events)
+	g.DELETE("/:id", ctrl.Cancel)
+}
+
+type SubmitDTO struct {
+	Op             string          `json:"op"    binding:"required"` // e.g. "engine:matmul", "logic:plan"
+	Input          json.RawMessage `json:"input" binding:"required"` // the body the synchronous route takes
+	TimeoutSeconds int             `json:"timeout_seconds"`          // optional; capped by the server
+}
+
+// Submit godoc
+// @Summary      Submit a background job
+// @Description  Queues an engine or logic operation and returns its job ID; poll /jobs/{id} for progress
+// @Tags         jobs
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  SubmitDTO  true  "Operation and its input"
+// @Success      202      {object}  Job
+// @Failure      400      {object}  map[string]any
+// @Failure      503      {object}  map[string]string
+// @Router       /jobs [post]
+func (c *Controller) Submit(ctx *gin.Context) {
+	var req SubmitDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
+		return
+	}
+	user := ctx.GetString(auth.CtxUserKey)
+	j, err := c.mgr.Submit(ctx.Request.Context(), user, req.Op, req.Input, time.Duration(req.TimeoutSeconds)*time.Second)
+	var inputErr *InputError
+	switch {
+	case errors.Is(err, ErrUnknownOp):
+		ops := c.mgr.Ops()
+		sort.Strings(ops)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "ops": ops, "request_id": requestid.Get(ctx)})
+		return
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrClosed):
+		ctx.Header("Retry-After", "5")
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	case errors.As(err, &inputErr):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue job", "request_id": requestid.Get(ctx)})
+		return
+	}
+	ctx.Header("Location", ctx.FullPath()+"/"+j.ID)
+	ctx.JSON(http.StatusAccepted, brief(j))
+}
+
+// List godoc
+// @Summary      List my jobs
+// @Description  The caller's jobs, newest first, without inputs or results
+// @Tags         jobs
+// @Produce      json
+// @Param        limit  query  int  false  "Maximum number of jobs"  default(50)
+// @Success      200    {object}  map[string]any
+// @Router       /jobs [get]
+func (c *Controller) List(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	list, err := c.mgr.List(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list jobs", "request_id": requestid.Get(ctx)})
+		return
+	}
+	if list == nil {
+		list = []*Job{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"jobs": list})
+}
+
+// Get godoc
+// @Summary      Job status
+// @Description  Status, timings and, once finished, the result or error of a job
+// @Tags         jobs
+// @Produce      json
+// @Param        id   path  string  true  "Job ID"
+// @Success      200  {object}  Job
+// @Failure      404  {object}  map[string]string
+// @Router       /jobs/{id} [get]
+func (c *Controller) Get(ctx *gin.Context) {
+	j, ok := c.load(ctx)
+	if !ok {
+		return
+	}
+	j.Input = nil
+	ctx.JSON(http.StatusOK, j)
+}
+
+// Result godoc
+// @Summary      Job result
+// @Description  The result body of a succeeded job, the error of a failed one (with the status the synchronous route would have used), or 409 while it is unfinished
+// @Tags         jobs
+// @Produce      json
+// @Param        id   path  string  true  "Job ID"
+// @Success      200  {object}  map[string]any
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /jobs/{id}/result [get]
+func (c *Controller) Result(ctx *gin.Context) {
+	j, ok := c.load(ctx)
+	if !ok {
+		return
+	}
+	switch j.Status {
+	case Succeeded:
+		ctx.Data(http.StatusOK, "application/json; charset=utf-8", j.Result)
+	case Failed:
+		kind := rpcerr.ParseKind(j.ErrorKind)
+		ctx.JSON(kind.Status(), gin.H{"error": j.Error, "kind": kind.String(), "request_id": requestid.Get(ctx)})
+	default:
+		ctx.JSON(http.StatusConflict, gin.H{"error": "job is " + string(j.Status), "status": j.Status, "request_id": requestid.Get(ctx)})
+	}
+}
+
+// Cancel godoc
+// @Summary      Cancel a job
+// @Description  Cancels a queued or running job; finished jobs are left as they are (409)
+// @Tags         jobs
+// @Produce      json
+// @Param        id   path  string  true  "Job ID"
+// @Success      200  {object}  Job
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  Job
+// @Router       /jobs/{id} [delete]
+func (c *Controller) Cancel(ctx *gin.Context) {
+	j, err := c.mgr.Cancel(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), ctx.Param("id"))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "job not found", "request_id": requestid.Get(ctx)})
+	case errors.Is(err, ErrFinished):
+		ctx.JSON(http.StatusConflict, brief(j))
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel job", "request_id": requestid.Get(ctx)})
+	default:
+		ctx.JSON(http.StatusOK, brief(j))
+	}
+}
+
+func (c *Controller) load(ctx *gin.Context) (*Job, bool) {
+	j, err := c.mgr.Get(ctx.Request.Context(), ctx.GetString(auth.CtxUserKey), ctx.Param("id"))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "job not found", "request_id": requestid.Get(ctx)})
+		return nil, false
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load job", "request_id": requestid.Get(ctx)})
+		return nil, false
+	}
+	return j, true
+}
+
+// brief drops the potentially large input and result.
+func brief(j *Job) *Job {
+	b := *j
+	b.Input, b.Result = nil, nil
+	return &b
+}
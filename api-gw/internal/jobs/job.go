@@ -0,0 +1,93 @@
+// Package jobs runs engine and logic operations in the background: clients
+// submit a job, poll its status and fetch the result later. Jobs are kept in
+// MySQL or Redis so that queued and interrupted work survives a restart.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type Status string
+
+const (
+	Queued    Status = "queued"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+	Cancelled Status = "cancelled"
+)
+
+// Terminal reports whether a job in this status will never change again.
+func (s Status) Terminal() bool {
+	return s == Succeeded || s == Failed || s == Cancelled
+}
+
+// Job is one submitted operation and, once finished, its outcome.
+type Job struct {
+	ID         string          `json:"id"`
+	User       string          `json:"user"`
+	Op         string          `json:"op"` // e.g. "engine:matmul"
+	Input      json.RawMessage `json:"input,omitempty"`
+	Status     Status          `json:"status"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	ErrorKind  string          `json:"error_kind,omitempty"` // rpcerr kind of a failed job
+	Timeout    time.Duration   `json:"-"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Op is an operation that can run as a job. Validate runs at submission so
+// bad input is refused before anything is queued.
+type Op interface {
+	Validate(input json.RawMessage) error
+	Run(ctx context.Context, input json.RawMessage) (any, error)
+}
+
+// Func adapts a typed function to an Op. The input is decoded as T and
+// checked with its binding tags and, if T has one, its Validate method.
+func Func[T any](run func(context.Context, T) (any, error)) Op {
+	return funcOp[T](run)
+}
+
+type funcOp[T any] func(context.Context, T) (any, error)
+
+func (f funcOp[T]) decode(input json.RawMessage) (T, error) {
+	var v T
+	if err := json.Unmarshal(input, &v); err != nil {
+		return v, err
+	}
+	if err := binding.Validator.ValidateStruct(&v); err != nil {
+		return v, err
+	}
+	if vv, ok := any(v).(interface{ Validate() error }); ok {
+		return v, vv.Validate()
+	}
+	return v, nil
+}
+
+func (f funcOp[T]) Validate(input json.RawMessage) error {
+	_, err := f.decode(input)
+	return err
+}
+
+func (f funcOp[T]) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	v, err := f.decode(input)
+	if err != nil {
+		return nil, err
+	}
+	return f(ctx, v)
+}
@@ -0,0 +1,430 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+var (
+	jobsFinished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "harmonia_jobs_total",
+		Help: "Background jobs by operation and final status.",
+	}, []string{"op", "status"})
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "harmonia_job_duration_seconds",
+		Help:    "Run time of background jobs.",
+		Buckets: []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"op"})
+)
+
+var (
+	ErrUnknownOp = errors.New("unknown job operation")
+	ErrQueueFull = errors.New("job queue is full")
+	ErrClosed    = errors.New("job manager is shutting down")
+	ErrFinished  = errors.New("job has already finished")
+)
+
+// InputError reports input that the operation refused at submission.
+type InputError struct{ Err error }
+
+func (e *InputError) Error() string { return "invalid input: " + e.Err.Error() }
+func (e *InputError) Unwrap() error { return e.Err }
+
+// errShutdown cancels running jobs when the gateway stops; they go back to
+// queued instead of being reported as cancelled.
+var errShutdown = errors.New("gateway shutting down")
+
+// Config sizes the worker pool and bounds job deadlines.
+type Config struct {
+	Workers        int
+	QueueSize      int
+	DefaultTimeout time.Duration
+	MaxTimeout     time.Duration
+	Retention      time.Duration // finished jobs are purged after this
+	RecoverEvery   time.Duration // how often to adopt orphaned jobs
+
+	// Event streams: how many events are kept per job, how long a finished
+	// job's stream stays replayable, and the SSE heartbeat interval.
+	EventBuffer int
+	EventLinger time.Duration
+	Heartbeat   time.Duration
+}
+
+// Manager accepts jobs, runs them on a fixed pool of workers and records
+// every transition in the store.
+type Manager struct {
+	cfg    Config
+	store  Store
+	ops    map[string]Op
+	queue  chan string
+	events *hub
+
+	ctx  context.Context
+	stop context.CancelCauseFunc
+	wg   sync.WaitGroup
+
+	mu       sync.Mutex
+	enqueued map[string]bool // in the queue channel
+	running  map[string]context.CancelCauseFunc
+	closed   bool
+}
+
+func NewManager(store Store, ops map[string]Op, cfg Config) *Manager {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.DefaultTimeout <= 0 {
+		cfg.DefaultTimeout = time.Minute
+	}
+	if cfg.MaxTimeout < cfg.DefaultTimeout {
+		cfg.MaxTimeout = cfg.DefaultTimeout
+	}
+	if cfg.RecoverEvery <= 0 {
+		cfg.RecoverEvery = time.Minute
+	}
+	if cfg.EventBuffer <= 0 {
+		cfg.EventBuffer = 512
+	}
+	if cfg.EventLinger <= 0 {
+		cfg.EventLinger = 5 * time.Minute
+	}
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = 15 * time.Second
+	}
+	ctx, stop := context.WithCancelCause(context.Background())
+	return &Manager{
+		cfg:      cfg,
+		store:    store,
+		ops:      ops,
+		queue:    make(chan string, max(cfg.QueueSize, cfg.Workers)),
+		events:   newHub(cfg.EventBuffer, cfg.EventLinger),
+		ctx:      ctx,
+		stop:     stop,
+		enqueued: make(map[string]bool),
+		running:  make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// Start launches the workers and re-queues jobs left over from a previous
+// run.
+func (m *Manager) Start() {
+	for range m.cfg.Workers {
+		m.wg.Add(1)
+		go m.work()
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.recover()
+		t := time.NewTicker(m.cfg.RecoverEvery)
+		defer t.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-t.C:
+				m.recover()
+			}
+		}
+	}()
+}
+
+// Close stops accepting jobs, interrupts running ones (they are re-queued
+// for the next start) and waits for the workers.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.stop(errShutdown)
+	m.wg.Wait()
+	return nil
+}
+
+// Ops lists the operation names jobs may run.
+func (m *Manager) Ops() []string {
+	out := make([]string, 0, len(m.ops))
+	for name := range m.ops {
+		out = append(out, name)
+	}
+	return out
+}
+
+// Submit validates input for op and queues a job for user. timeout <= 0
+// picks the default; longer ones are capped.
+func (m *Manager) Submit(ctx context.Context, user, op string, input json.RawMessage, timeout time.Duration) (*Job, error) {
+	o, ok := m.ops[op]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownOp, op)
+	}
+	if err := o.Validate(input); err != nil {
+		return nil, &InputError{Err: err}
+	}
+	if timeout <= 0 {
+		timeout = m.cfg.DefaultTimeout
+	}
+	timeout = min(timeout, m.cfg.MaxTimeout)
+
+	m.mu.Lock()
+	closed := m.closed
+	m.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	if len(m.queue) == cap(m.queue) {
+		return nil, ErrQueueFull
+	}
+
+	j := &Job{
+		ID:        newID(),
+		User:      user,
+		Op:        op,
+		Input:     input,
+		Status:    Queued,
+		Timeout:   timeout,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := m.store.Create(ctx, j); err != nil {
+		return nil, err
+	}
+	m.events.publish(j.ID, "status", statusEvent(j))
+	// if the last slot went meanwhile the job stays queued in the store and
+	// the next recovery pass picks it up
+	m.enqueue(j.ID)
+	return j, nil
+}
+
+// Get returns user's job; other users' jobs are reported as not found.
+func (m *Manager) Get(ctx context.Context, user, id string) (*Job, error) {
+	j, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if j.User != user {
+		return nil, ErrNotFound
+	}
+	return j, nil
+}
+
+func (m *Manager) List(ctx context.Context, user string, limit int) ([]*Job, error) {
+	return m.store.List(ctx, user, limit)
+}
+
+// Cancel stops user's job. A queued job never runs; a running one has its
+// context cancelled and its eventual result discarded.
+func (m *Manager) Cancel(ctx context.Context, user, id string) (*Job, error) {
+	j, err := m.Get(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if j.Status.Terminal() {
+		return j, ErrFinished
+	}
+	now := time.Now().UTC()
+	j.Status, j.FinishedAt = Cancelled, &now
+	ok, err := m.store.Update(ctx, j, Queued, Running)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// finished meanwhile
+		if j, err = m.store.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return j, ErrFinished
+	}
+	m.mu.Lock()
+	if cancel, ok := m.running[id]; ok {
+		cancel(context.Canceled)
+	}
+	m.mu.Unlock()
+	m.events.finish(id, "result", resultEvent(j))
+	jobsFinished.WithLabelValues(j.Op, string(Cancelled)).Inc()
+	return j, nil
+}
+
+// enqueue hands id to the workers unless it is already waiting; it reports
+// false when the queue is full.
+func (m *Manager) enqueue(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.enqueued[id] {
+		return true
+	}
+	select {
+	case m.queue <- id:
+		m.enqueued[id] = true
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *Manager) work() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case id := <-m.queue:
+			m.mu.Lock()
+			delete(m.enqueued, id)
+			m.mu.Unlock()
+			m.run(id)
+		}
+	}
+}
+
+func (m *Manager) run(id string) {
+	j, err := m.store.Get(m.ctx, id)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.Warn("job: load failed", "job", id, "err", err)
+		}
+		return
+	}
+	if j.Status != Queued {
+		return
+	}
+	op, ok := m.ops[j.Op]
+	if !ok {
+		m.finish(j, nil, fmt.Errorf("%w %q", ErrUnknownOp, j.Op))
+		return
+	}
+
+	start := time.Now().UTC()
+	j.Status, j.StartedAt = Running, &start
+	if ok, err := m.store.Update(m.ctx, j, Queued); err != nil || !ok {
+		// cancelled, or another instance got there first
+		return
+	}
+
+	m.events.publish(id, "status", statusEvent(j))
+
+	ctx, cancel := context.WithCancelCause(m.ctx)
+	ctx, cancelTimeout := context.WithTimeout(ctx, j.Timeout)
+	ctx = context.WithValue(ctx, reporterKey{}, func(typ string, data any) {
+		m.events.publish(id, typ, data)
+	})
+	m.mu.Lock()
+	m.running[id] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, id)
+		m.mu.Unlock()
+		cancelTimeout()
+		cancel(nil)
+	}()
+
+	slog.Info("job started", "job", id, "op", j.Op, "user", j.User, "timeout", j.Timeout)
+	res, err := op.Run(ctx, j.Input)
+	jobDuration.WithLabelValues(j.Op).Observe(time.Since(start).Seconds())
+
+	switch cause := context.Cause(ctx); {
+	case errors.Is(cause, errShutdown):
+		// put it back for whoever runs next
+		j.Status, j.StartedAt = Queued, nil
+		if _, err := m.store.Update(context.Background(), j, Running); err != nil {
+			slog.Warn("job: requeue failed", "job", id, "err", err)
+		}
+		m.events.publish(id, "status", statusEvent(j))
+		return
+	case errors.Is(cause, context.Canceled):
+		// Cancel already recorded it
+		return
+	}
+	m.finish(j, res, err)
+}
+
+// finish records the outcome of a running job unless it was cancelled
+// meanwhile.
+func (m *Manager) finish(j *Job, res any, err error) {
+	now := time.Now().UTC()
+	j.FinishedAt = &now
+	if err == nil {
+		j.Result, err = json.Marshal(res)
+	}
+	if err != nil {
+		j.Status, j.Error, j.ErrorKind = Failed, err.Error(), rpcerr.KindOf(err).String()
+	} else {
+		j.Status = Succeeded
+	}
+	ok, uerr := m.store.Update(context.WithoutCancel(m.ctx), j, Running, Queued)
+	if uerr != nil {
+		slog.Error("job: save result failed", "job", j.ID, "err", uerr)
+		return
+	}
+	if ok {
+		m.events.finish(j.ID, "result", resultEvent(j))
+		jobsFinished.WithLabelValues(j.Op, string(j.Status)).Inc()
+		slog.Info("job finished", "job", j.ID, "op", j.Op, "status", j.Status, "err", j.Error)
+	}
+}
+
+// recover queues jobs that no worker holds: queued ones whose enqueue was
+// lost (a restart, a full queue) and running ones whose deadline passed
+// long ago, whose gateway must have died. It also purges old jobs.
+func (m *Manager) recover() {
+	m.events.sweep()
+	ctx, cancel := context.WithTimeout(m.ctx, 10*time.Second)
+	defer cancel()
+	pending, err := m.store.Pending(ctx)
+	if err != nil {
+		slog.Warn("job: recovery failed", "err", err)
+		return
+	}
+	for _, j := range pending {
+		m.mu.Lock()
+		_, held := m.running[j.ID]
+		m.mu.Unlock()
+		if held {
+			continue
+		}
+		if j.Status == Running {
+			if j.StartedAt == nil || time.Since(*j.StartedAt) < j.Timeout+m.cfg.RecoverEvery {
+				continue
+			}
+			j.Status, j.StartedAt = Queued, nil
+			if ok, err := m.store.Update(ctx, j, Running); err != nil || !ok {
+				continue
+			}
+			slog.Info("job: re-queued orphan", "job", j.ID, "op", j.Op)
+		}
+		if !m.enqueue(j.ID) {
+			break
+		}
+	}
+
+	if m.cfg.Retention > 0 {
+		if n, err := m.store.Purge(ctx, time.Now().Add(-m.cfg.Retention)); err != nil {
+			slog.Warn("job: purge failed", "err", err)
+		} else if n > 0 {
+			slog.Info("job: purged finished jobs", "count", n)
+		}
+	}
+}
+
+func statusEvent(j *Job) map[string]any {
+	return map[string]any{"status": j.Status, "at": time.Now().UTC()}
+}
+
+func resultEvent(j *Job) map[string]any {
+	ev := map[string]any{"status": j.Status}
+	if len(j.Result) > 0 {
+		ev["result"] = j.Result
+	}
+	if j.Error != "" {
+		ev["error"], ev["kind"] = j.Error, j.ErrorKind
+	}
+	return ev
+}
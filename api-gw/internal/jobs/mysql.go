@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+func RunMigrations(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS jobs (
+		id          CHAR(24)    PRIMARY KEY,
+		username    VARCHAR(64) NOT NULL,
+		op          VARCHAR(64) NOT NULL,
+		input       LONGBLOB    NOT NULL,
+		status      VARCHAR(16) NOT NULL,
+		result      LONGBLOB    NULL,
+		error       TEXT        NULL,
+		error_kind  VARCHAR(32) NULL,
+		timeout_ms  BIGINT      NOT NULL,
+		created_at  DATETIME(3) NOT NULL,
+		started_at  DATETIME(3) NULL,
+		finished_at DATETIME(3) NULL,
+		KEY idx_jobs_user (username, created_at),
+		KEY idx_jobs_status (status, created_at)
+		) ENGINE=InnoDB;`)
+	return err
+}
+
+// MySQLStore keeps jobs in the jobs table.
+type MySQLStore struct{ db *sql.DB }
+
+func NewMySQLStore(db *sql.DB) *MySQLStore { return &MySQLStore{db: db} }
+
+const jobColumns = `id, username, op, input, status, result, error, error_kind, timeout_ms, created_at, started_at, finished_at`
+
+func (s *MySQLStore) Create(ctx context.Context, j *Job) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO jobs (`+jobColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		j.ID, j.User, j.Op, []byte(j.Input), j.Status, nullBytes(j.Result), nullString(j.Error), nullString(j.ErrorKind),
+		j.Timeout.Milliseconds(), j.CreatedAt.UTC(), utc(j.StartedAt), utc(j.FinishedAt))
+	return err
+}
+
+func (s *MySQLStore) Get(ctx context.Context, id string) (*Job, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id=?`, id)
+	j, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return j, err
+}
+
+func (s *MySQLStore) List(ctx context.Context, user string, limit int) ([]*Job, error) {
+	return s.query(ctx,
+		`SELECT id, username, op, '', status, NULL, error, error_kind, timeout_ms, created_at, started_at, finished_at
+		 FROM jobs WHERE username=? ORDER BY created_at DESC LIMIT ?`, user, limit)
+}
+
+func (s *MySQLStore) Update(ctx context.Context, j *Job, from ...Status) (bool, error) {
+	args := []any{j.Status, nullBytes(j.Result), nullString(j.Error), nullString(j.ErrorKind), utc(j.StartedAt), utc(j.FinishedAt), j.ID}
+	for _, st := range from {
+		args = append(args, st)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE jobs SET status=?, result=?, error=?, error_kind=?, started_at=?, finished_at=?
+		 WHERE id=? AND status IN (?`+strings.Repeat(", ?", len(from)-1)+`)`, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *MySQLStore) Pending(ctx context.Context) ([]*Job, error) {
+	return s.query(ctx, `SELECT `+jobColumns+` FROM jobs WHERE status IN (?, ?) ORDER BY created_at`, Queued, Running)
+}
+
+func (s *MySQLStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM jobs WHERE finished_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *MySQLStore) query(ctx context.Context, q string, args ...any) ([]*Job, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+func scanJob(row interface{ Scan(...any) error }) (*Job, error) {
+	var (
+		j                 Job
+		input, result     []byte
+		errMsg, errKind   sql.NullString
+		timeoutMS         int64
+		started, finished sql.NullTime
+	)
+	if err := row.Scan(&j.ID, &j.User, &j.Op, &input, &j.Status, &result, &errMsg, &errKind,
+		&timeoutMS, &j.CreatedAt, &started, &finished); err != nil {
+		return nil, err
+	}
+	if len(input) > 0 {
+		j.Input = input
+	}
+	if len(result) > 0 {
+		j.Result = result
+	}
+	j.Error, j.ErrorKind = errMsg.String, errKind.String
+	j.Timeout = time.Duration(timeoutMS) * time.Millisecond
+	if started.Valid {
+		j.StartedAt = &started.Time
+	}
+	if finished.Valid {
+		j.FinishedAt = &finished.Time
+	}
+	return &j, nil
+}
+
+func nullBytes(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func utc(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
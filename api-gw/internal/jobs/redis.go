@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps each job as JSON under {ns}{id}, with a per-user sorted
+// set for listing and a set of unfinished job IDs. Finished jobs expire
+// after the retention period, which takes the place of Purge.
+type RedisStore struct {
+	rdb       *redis.Client
+	keyNS     string
+	retention time.Duration
+}
+
+func NewRedisStore(rdb *redis.Client, ns string, retention time.Duration) *RedisStore {
+	return &RedisStore{rdb: rdb, keyNS: ns, retention: retention}
+}
+
+// redisJob carries the fields Job leaves out of its JSON form.
+type redisJob struct {
+	*Job
+	TimeoutMS int64 `json:"timeout_ms"`
+}
+
+func (s *RedisStore) key(id string) string       { return s.keyNS + id }
+func (s *RedisStore) userKey(user string) string { return s.keyNS + "user:" + user }
+func (s *RedisStore) pendingKey() string         { return s.keyNS + "pending" }
+
+func (s *RedisStore) Create(ctx context.Context, j *Job) error {
+	b, err := json.Marshal(redisJob{Job: j, TimeoutMS: j.Timeout.Milliseconds()})
+	if err != nil {
+		return err
+	}
+	_, err = s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, s.key(j.ID), b, 0)
+		p.ZAdd(ctx, s.userKey(j.User), redis.Z{Score: float64(j.CreatedAt.UnixMilli()), Member: j.ID})
+		p.SAdd(ctx, s.pendingKey(), j.ID)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (*Job, error) {
+	return s.get(ctx, s.rdb, id)
+}
+
+func (s *RedisStore) get(ctx context.Context, c redis.Cmdable, id string) (*Job, error) {
+	b, err := c.Get(ctx, s.key(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(b)
+}
+
+func decode(b []byte) (*Job, error) {
+	rj := redisJob{Job: new(Job)}
+	if err := json.Unmarshal(b, &rj); err != nil {
+		return nil, err
+	}
+	rj.Job.Timeout = time.Duration(rj.TimeoutMS) * time.Millisecond
+	return rj.Job, nil
+}
+
+func (s *RedisStore) List(ctx context.Context, user string, limit int) ([]*Job, error) {
+	ids, err := s.rdb.ZRevRange(ctx, s.userKey(user), 0, int64(limit)-1).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	jobs, missing, err := s.mget(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		// expired jobs leave their IDs behind in the index
+		s.rdb.ZRem(ctx, s.userKey(user), missing...)
+	}
+	for _, j := range jobs {
+		j.Input, j.Result = nil, nil
+	}
+	return jobs, nil
+}
+
+func (s *RedisStore) mget(ctx context.Context, ids []string) ([]*Job, []any, error) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.key(id)
+	}
+	vals, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		out     []*Job
+		missing []any
+	)
+	for i, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			missing = append(missing, ids[i])
+			continue
+		}
+		j, err := decode([]byte(str))
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, j)
+	}
+	return out, missing, nil
+}
+
+func (s *RedisStore) Update(ctx context.Context, j *Job, from ...Status) (bool, error) {
+	b, err := json.Marshal(redisJob{Job: j, TimeoutMS: j.Timeout.Milliseconds()})
+	if err != nil {
+		return false, err
+	}
+	key := s.key(j.ID)
+	for range 3 {
+		updated := false
+		err = s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			cur, err := s.get(ctx, tx, j.ID)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(from, cur.Status) {
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+				if j.Status.Terminal() {
+					p.Set(ctx, key, b, s.retention)
+					p.SRem(ctx, s.pendingKey(), j.ID)
+				} else {
+					p.Set(ctx, key, b, 0)
+					p.SAdd(ctx, s.pendingKey(), j.ID)
+				}
+				return nil
+			})
+			updated = err == nil
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return updated, err
+		}
+	}
+	return false, err
+}
+
+func (s *RedisStore) Pending(ctx context.Context) ([]*Job, error) {
+	ids, err := s.rdb.SMembers(ctx, s.pendingKey()).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	jobs, missing, err := s.mget(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		s.rdb.SRem(ctx, s.pendingKey(), missing...)
+	}
+	slices.SortFunc(jobs, func(a, b *Job) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return jobs, nil
+}
+
+func (s *RedisStore) Purge(context.Context, time.Time) (int64, error) { return 0, nil }
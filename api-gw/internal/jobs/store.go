@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("job not found")
+
+// Store persists jobs. Update is a compare-and-set on status so that
+// workers, cancellations and other gateway instances never overwrite a
+// transition they did not see.
+type Store interface {
+	Create(ctx context.Context, j *Job) error
+	Get(ctx context.Context, id string) (*Job, error)
+	// List returns a user's jobs, newest first, without inputs or results.
+	List(ctx context.Context, user string, limit int) ([]*Job, error)
+	// Update stores j if the stored job is in one of the from statuses and
+	// reports whether it did.
+	Update(ctx context.Context, j *Job, from ...Status) (bool, error)
+	// Pending returns queued and running jobs, oldest first.
+	Pending(ctx context.Context) ([]*Job, error)
+	// Purge deletes jobs that finished before the cutoff.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
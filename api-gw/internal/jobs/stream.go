@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Events godoc
+// @Summary      Stream job events (SSE)
+// @Description  Server-Sent Events for one job: "status" on every transition, "progress" with partial results (e.g. running π estimates), "task" per planner task, and a final "result". Comment lines are heartbeats. A "shutdown" event means the gateway is draining: reconnect with Last-Event-ID (or last_event_id) to resume.
+// @Tags         jobs
+// @Produce      text/event-stream
+// @Param        id             path    string  true   "Job ID"
+// @Param        Last-Event-ID  header  string  false  "ID of the last event received"
+// @Param        last_event_id  query   string  false  "Same as Last-Event-ID, for clients that cannot set headers"
+// @Success      200  {string}  string  "event stream"
+// @Failure      404  {object}  map[string]string
+// @Router       /jobs/{id}/events [get]
+func (c *Controller) Events(ctx *gin.Context) {
+	j, ok := c.load(ctx)
+	if !ok {
+		return
+	}
+	last := ctx.GetHeader("Last-Event-ID")
+	if last == "" {
+		last = ctx.Query("last_event_id")
+	}
+	lastID, _ := strconv.ParseInt(last, 10, 64)
+
+	w := ctx.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // don't let nginx buffer the stream
+	// the server's write timeout is for ordinary requests
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	w.Flush()
+
+	m := c.mgr
+	notify, unsubscribe, live := m.events.subscribe(j.ID)
+	if !live {
+		c.pollEvents(ctx, j)
+		return
+	}
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(m.cfg.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		evs, done := m.events.since(j.ID, lastID)
+		for _, e := range evs {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+			lastID = e.ID
+		}
+		w.Flush()
+		if done {
+			return
+		}
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-c.closing:
+			goAway(w)
+			return
+		case <-notify:
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+// pollEvents serves a job this gateway holds no events for (it runs on
+// another instance, or finished long ago) by watching the store. Only
+// status changes and the result are available this way, without IDs.
+func (c *Controller) pollEvents(ctx *gin.Context, j *Job) {
+	w := ctx.Writer
+	send := func(typ string, data any) {
+		b, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ, b)
+		w.Flush()
+	}
+
+	poll := time.NewTicker(time.Second)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(c.mgr.cfg.Heartbeat)
+	defer heartbeat.Stop()
+	var seen Status
+	for {
+		if j.Status != seen {
+			seen = j.Status
+			if j.Status.Terminal() {
+				send("result", resultEvent(j))
+				return
+			}
+			send("status", statusEvent(j))
+		}
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-c.closing:
+			goAway(w)
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		case <-poll.C:
+			next, err := c.mgr.store.Get(ctx.Request.Context(), j.ID)
+			if err != nil {
+				continue
+			}
+			j = next
+		}
+	}
+}
+
+// goAway tells the client the stream ends because the gateway is shutting
+// down, and to reconnect (to another instance) promptly rather than after
+// the usual retry delay.
+func goAway(w gin.ResponseWriter) {
+	fmt.Fprint(w, "retry: 500\nevent: shutdown\ndata: {}\n\n")
+	w.Flush()
+}
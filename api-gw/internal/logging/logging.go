@@ -0,0 +1,137 @@
+// Package logging configures log/slog for the gateway and writes one
+// structured access log line per request, enriched with what happened while
+// serving it (cache outcome, backend RPC timings).
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+)
+
+// Setup installs the default slog logger (which the log package then also
+// writes through). format is "json" or "text"; level is debug, info, warn or
+// error.
+func Setup(level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("logging: bad level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		h = slog.NewJSONHandler(os.Stdout, opts)
+	case "text":
+		h = slog.NewTextHandler(os.Stdout, opts)
+	default:
+		return fmt.Errorf("logging: bad format %q", format)
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// RPC is one backend attempt as it appears in the access log.
+type RPC struct {
+	Backend string  `json:"backend"`
+	Method  string  `json:"method"`
+	MS      float64 `json:"ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// entry collects what lower layers report about the request in flight.
+type entry struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+	rpcs  []RPC
+}
+
+type entryKey struct{}
+
+// Annotate adds attrs to the access log line of the request ctx belongs to;
+// outside a request it does nothing.
+func Annotate(ctx context.Context, attrs ...slog.Attr) {
+	if e, ok := ctx.Value(entryKey{}).(*entry); ok {
+		e.mu.Lock()
+		e.attrs = append(e.attrs, attrs...)
+		e.mu.Unlock()
+	}
+}
+
+// RecordRPC notes a backend attempt for the access log.
+func RecordRPC(ctx context.Context, backend, method string, d time.Duration, err error) {
+	e, ok := ctx.Value(entryKey{}).(*entry)
+	if !ok {
+		return
+	}
+	r := RPC{Backend: backend, Method: method, MS: float64(d.Microseconds()) / 1000}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	e.mu.Lock()
+	e.rpcs = append(e.rpcs, r)
+	e.mu.Unlock()
+}
+
+// quiet routes are polled constantly; they are logged at debug level.
+var quiet = map[string]bool{"/metrics": true, "/api/healthz": true, "/api/readyz": true}
+
+// AccessLog writes one line per request once it completes. It must run
+// after requestid.Middleware.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		e := &entry{}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), entryKey{}, e))
+		c.Next()
+
+		status := c.Writer.Status()
+		route := c.FullPath()
+		attrs := []slog.Attr{
+			slog.String("request_id", requestid.Get(c)),
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+		if user := c.GetString(auth.CtxUserKey); user != "" {
+			attrs = append(attrs, slog.String("user", user))
+		}
+		e.mu.Lock()
+		attrs = append(attrs, e.attrs...)
+		if len(e.rpcs) > 0 {
+			attrs = append(attrs, slog.Any("rpcs", append([]RPC(nil), e.rpcs...)))
+		}
+		e.mu.Unlock()
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		lvl := slog.LevelInfo
+		switch {
+		case status >= 500:
+			lvl = slog.LevelError
+		case status >= 400:
+			lvl = slog.LevelWarn
+		case quiet[route]:
+			lvl = slog.LevelDebug
+		}
+		slog.LogAttrs(c.Request.Context(), lvl, "request", attrs...)
+	}
+}
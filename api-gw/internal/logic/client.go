@@ -2,44 +2,196 @@ package logic
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/balancer/leastrequest" // registers least_request_experimental
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
 
 	lg "github.com/Patrick8894/harmonia/api-gw/gen/logic/v1"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
 )
 
-type Client struct {
-	addr string
+// KeepaliveConfig controls HTTP/2 pings on the shared LogicService channel.
+type KeepaliveConfig struct {
+	Time                time.Duration // ping after this much inactivity
+	Timeout             time.Duration // wait this long for a ping ack
+	PermitWithoutStream bool
 }
 
-func NewClient(addr string) *Client { return &Client{addr: addr} }
+// BalancerConfig picks how the channel spreads RPCs over logic replicas.
+type BalancerConfig struct {
+	Policy      string // "round_robin" (default) | "least_request" | "pick_first"
+	HealthCheck bool   // watch grpc.health.v1 and skip NOT_SERVING replicas
+}
 
-func (c *Client) dial() (*grpc.ClientConn, lg.LogicServiceClient, error) {
-	conn, err := grpc.NewClient(
-		c.addr,
+// Client owns a single long-lived ClientConn; all RPCs multiplex over it.
+// addr may be a single host:port, a comma-separated list of them, or any
+// gRPC target such as "dns:///logic:9002" (re-resolved by the channel).
+type Client struct {
+	addr  string
+	conn  *grpc.ClientConn
+	cli   lg.LogicServiceClient
+	state atomic.Value // connectivity.State
+	done  chan struct{}
+	res   *resilience.Executor
+}
+
+func NewClient(addr string, ka KeepaliveConfig, bc BalancerConfig, rc resilience.Config) (*Client, error) {
+	target, opts := dialTarget(addr)
+	opts = append(opts,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                ka.Time,
+			Timeout:             ka.Timeout,
+			PermitWithoutStream: ka.PermitWithoutStream,
+		}),
+		grpc.WithDefaultServiceConfig(serviceConfig(bc)),
+		// client span per RPC; traceparent travels in the request metadata
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(forwardRequestID),
 	)
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	c := &Client{
+		addr: addr,
+		conn: conn,
+		cli:  lg.NewLogicServiceClient(conn),
+		done: make(chan struct{}),
+		// All LogicService RPCs are side-effect free, so the default policy applies.
+		res: resilience.New("logic", rc, nil),
 	}
-	return conn, lg.NewLogicServiceClient(conn), nil
+	c.state.Store(conn.GetState())
+	conn.Connect() // leave IDLE now instead of on the first request
+	go c.watchState()
+	return c, nil
 }
 
-func (c *Client) hello(ctx context.Context, name string) (string, error) {
-	conn, cli, err := c.dial()
+// dialTarget turns a comma-separated address list into a static manual
+// resolver; anything else is handed to grpc as-is.
+func dialTarget(addr string) (string, []grpc.DialOption) {
+	if !strings.Contains(addr, ",") {
+		return addr, nil
+	}
+	var eps []resolver.Endpoint
+	for _, a := range strings.Split(addr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			eps = append(eps, resolver.Endpoint{Addresses: []resolver.Address{{Addr: a}}})
+		}
+	}
+	r := manual.NewBuilderWithScheme("logic-static")
+	r.InitialState(resolver.State{Endpoints: eps})
+	return r.Scheme() + ":///logic", []grpc.DialOption{grpc.WithResolvers(r)}
+}
+
+// forwardRequestID sends the gateway's request ID as x-request-id metadata
+// so logic-side logs can be correlated with ours.
+func forwardRequestID(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id := requestid.From(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey, id)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func serviceConfig(bc BalancerConfig) string {
+	policy := bc.Policy
+	switch policy {
+	case "", "round_robin":
+		policy = "round_robin"
+	case "least_request":
+		policy = "least_request_experimental"
+	}
+	sc := fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]`, policy)
+	if bc.HealthCheck {
+		sc += `,"healthCheckConfig":{"serviceName":""}`
+	}
+	return sc + "}"
+}
+
+// State returns the last observed connectivity state of the channel.
+func (c *Client) State() connectivity.State {
+	return c.state.Load().(connectivity.State)
+}
+
+// Ping asks grpc.health.v1 for the overall server status, falling back to
+// Hello when the server doesn't implement it. Retries and the circuit
+// breaker are bypassed.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		_, err = c.cli.Hello(ctx, &lg.HelloRequest{Name: "healthcheck"})
+		return rpcerr.FromGRPC("logic", err)
+	}
 	if err != nil {
-		return "", err
+		return rpcerr.FromGRPC("logic", err)
+	}
+	if st := resp.GetStatus(); st != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("logic: %s", st)
 	}
-	defer conn.Close()
+	return nil
+}
 
-	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+// Breaker exposes the circuit breaker guarding the logic service.
+func (c *Client) Breaker() *resilience.Breaker { return c.res.Breaker() }
+
+// Close tears down the channel and stops the state watcher.
+func (c *Client) Close() error {
+	select {
+	case <-c.done:
+		return nil
+	default:
+		close(c.done)
+	}
+	return c.conn.Close()
+}
+
+func (c *Client) watchState() {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-c.done
+		cancel()
+	}()
+
+	st := c.conn.GetState()
+	for {
+		c.state.Store(st)
+		if st == connectivity.Shutdown || !c.conn.WaitForStateChange(ctx, st) {
+			return
+		}
+		next := c.conn.GetState()
+		slog.Info("logic: channel state changed", "target", c.addr, "from", st.String(), "to", next.String())
+		st = next
+	}
+}
+
+func (c *Client) hello(ctx context.Context, name string) (string, error) {
+	ctx, cancel := withDefaultTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	resp, err := cli.Hello(ctx, &lg.HelloRequest{Name: name})
+	var resp *lg.HelloReply
+	err := c.res.Do(ctx, "Hello", func(ctx context.Context) (err error) {
+		resp, err = c.cli.Hello(ctx, &lg.HelloRequest{Name: name})
+		return rpcerr.FromGRPC("logic", err)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -47,43 +199,50 @@ func (c *Client) hello(ctx context.Context, name string) (string, error) {
 }
 
 func (c *Client) evaluate(ctx context.Context, in *lg.EvalRequest) (*lg.EvalReply, error) {
-	conn, cli, err := c.dial()
-	if err != nil {
-		return nil, err
-	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	ctx, cancel := withDefaultTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	return cli.Evaluate(ctx, in)
+	var resp *lg.EvalReply
+	err := c.res.Do(ctx, "Evaluate", func(ctx context.Context) (err error) {
+		resp, err = c.cli.Evaluate(ctx, in)
+		return rpcerr.FromGRPC("logic", err)
+	})
+	return resp, err
 }
 
 func (c *Client) transform(ctx context.Context, in *lg.TransformRequest) (*lg.TransformReply, error) {
-	conn, cli, err := c.dial()
-	if err != nil {
-		return nil, err
-	}
-	defer conn.Close()
-
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	ctx, cancel := withDefaultTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	return cli.Transform(ctx, in)
+	var resp *lg.TransformReply
+	err := c.res.Do(ctx, "Transform", func(ctx context.Context) (err error) {
+		resp, err = c.cli.Transform(ctx, in)
+		return rpcerr.FromGRPC("logic", err)
+	})
+	return resp, err
 }
 
 func (c *Client) planTasks(ctx context.Context, in *lg.PlanRequest) (*lg.PlanReply, error) {
-	conn, cli, err := c.dial()
-	if err != nil {
-		return nil, err
-	}
-	defer conn.Close()
-
 	// Planning can take longer
-	ctx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	ctx, cancel := withDefaultTimeout(ctx, 8*time.Second)
 	defer cancel()
 
-	return cli.PlanTasks(ctx, in)
+	var resp *lg.PlanReply
+	err := c.res.Do(ctx, "PlanTasks", func(ctx context.Context) (err error) {
+		resp, err = c.cli.PlanTasks(ctx, in)
+		return rpcerr.FromGRPC("logic", err)
+	})
+	return resp, err
+}
+
+// withDefaultTimeout bounds calls that arrive without a deadline. A caller's
+// own deadline (the handler's, a job's or a pipeline's) is left alone, so
+// long-running jobs are not cut short here.
+func withDefaultTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
 }
 
 // helper: map string/number to proto enum
@@ -0,0 +1,12 @@
+package logic
+
+import "github.com/Patrick8894/harmonia/api-gw/internal/ratelimit"
+
+// CostRoutes prices logic requests for the compute quota, keyed by
+// ratelimit.Route. Single calls cost the default unit; a batch costs one
+// per item.
+func CostRoutes() map[string]ratelimit.CostFunc {
+	return map[string]ratelimit.CostFunc{
+		"logic:eval:batch": ratelimit.BodyCost(func(items []EvalDTO) float64 { return float64(len(items)) }),
+	}
+}
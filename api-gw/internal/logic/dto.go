@@ -8,7 +8,7 @@ type EvalDTO struct {
 }
 
 type TransformDTO struct {
-	Data    []float64 `json:"data"     binding:"required"`
+	Data    []float64 `json:"data"     binding:"required"`        // or "dataset_id" in its place (see /datasets)
 	Expr    string    `json:"expression"`                         // optional
 	VarName string    `json:"var_name"`                           // optional
 	Op      string    `json:"operation"       binding:"required"` // "MAP" | "FILTER" | "SUM" (case-insensitive) or number
@@ -7,21 +7,55 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/batch"
+	"github.com/Patrick8894/harmonia/api-gw/internal/httpcache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
 )
 
+// Timeouts bound each route's request; zero fields keep the defaults.
+type Timeouts struct {
+	Evaluate  time.Duration
+	Transform time.Duration
+	Plan      time.Duration
+	Batch     time.Duration
+}
+
+func (t Timeouts) withDefaults() Timeouts {
+	or := func(d, def time.Duration) time.Duration {
+		if d > 0 {
+			return d
+		}
+		return def
+	}
+	return Timeouts{
+		Evaluate:  or(t.Evaluate, 3*time.Second),
+		Transform: or(t.Transform, 5*time.Second),
+		Plan:      or(t.Plan, 8*time.Second),
+		Batch:     or(t.Batch, 10*time.Second),
+	}
+}
+
 type Controller struct {
-	svc *Service
+	svc      *Service
+	batch    batch.Config
+	timeouts Timeouts
 }
 
-func NewController(svc *Service) *Controller { return &Controller{svc: svc} }
+func NewController(svc *Service, bc batch.Config, t Timeouts) *Controller {
+	return &Controller{svc: svc, batch: bc, timeouts: t.withDefaults()}
+}
 
 // Wire routes to named methods.
 func Register(rg *gin.RouterGroup, ctrl *Controller) {
 	g := rg.Group("/logic")
 	g.GET("/hello", ctrl.Hello)
 	g.POST("/eval", ctrl.Evaluate)
+	g.POST("/eval"+batch.Suffix, batch.Only(ctrl.EvaluateBatch))
 	g.POST("/transform", ctrl.Transform)
 	g.POST("/plan", ctrl.Plan)
+	g.GET("/channel", ctrl.Channel)
 }
 
 // HelloLogicRPC godoc
@@ -31,7 +65,9 @@ func Register(rg *gin.RouterGroup, ctrl *Controller) {
 // @Produce      json
 // @Param        name  query  string  false  "Name to greet"  default(World)
 // @Success      200   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Failure      502   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Failure      504   {object}  map[string]string
 // @Router       /logic/hello [get]
 func (c *Controller) Hello(ctx *gin.Context) {
 	name := ctx.DefaultQuery("name", "World")
@@ -40,7 +76,7 @@ func (c *Controller) Hello(ctx *gin.Context) {
 
 	msg, err := c.svc.Hello(reqCtx, name)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": msg})
@@ -53,29 +89,63 @@ func (c *Controller) Hello(ctx *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        payload  body  EvalDTO  true  "Eval input"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200      {object}  map[string]any
+// @Success      304      "Not modified (If-None-Match matched the ETag)"
 // @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      422      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Failure      504      {object}  map[string]string
 // @Router       /logic/eval [post]
 func (c *Controller) Evaluate(ctx *gin.Context) {
 	var req EvalDTO
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
 		return
 	}
-	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 3*time.Second)
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), c.timeouts.Evaluate)
 	defer cancel()
 
-	resp, fromCache, err := c.svc.Evaluate(reqCtx, req)
+	resp, meta, err := c.svc.Evaluate(reqCtx, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
+		return
+	}
+	httpcache.JSON(ctx, meta, httpcache.WithMeta(evalResult(resp), meta))
+}
+
+// EvaluateBatch godoc
+// @Summary      Evaluate expressions, batched
+// @Description  Evaluates each expression in the array; cache hits are fetched in one lookup and misses fan out with bounded concurrency. Items come back in order with the status the single route would have used.
+// @Tags         logic
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  []EvalDTO  true  "Array of eval inputs"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Success      200      {object}  map[string]any
+// @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Router       /logic/eval:batch [post]
+func (c *Controller) EvaluateBatch(ctx *gin.Context) {
+	var req []EvalDTO
+	if !batch.Bind(ctx, c.batch, &req) {
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"result": resp.GetResult(),
-		"error":  resp.GetError(),
-		"cached": fromCache,
-	})
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), c.timeouts.Batch)
+	defer cancel()
+
+	items := c.svc.EvaluateBatch(reqCtx, req, c.batch.Concurrency)
+	out := make([]batch.Item, len(items))
+	for i, it := range items {
+		if it.Err != nil {
+			out[i] = batch.Failed(it.Err)
+		} else {
+			out[i] = batch.OK(httpcache.WithMeta(evalResult(it.Value), it.Meta))
+		}
+	}
+	batch.Respond(ctx, out)
 }
 
 // Transform godoc
@@ -85,30 +155,31 @@ func (c *Controller) Evaluate(ctx *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        payload  body  TransformDTO  true  "Transform input"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200      {object}  map[string]any
+// @Success      304      "Not modified (If-None-Match matched the ETag)"
 // @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      422      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Failure      504      {object}  map[string]string
 // @Router       /logic/transform [post]
 func (c *Controller) Transform(ctx *gin.Context) {
 	var req TransformDTO
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
 		return
 	}
-	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), c.timeouts.Transform)
 	defer cancel()
 
-	resp, fromCache, err := c.svc.Transform(reqCtx, req)
+	resp, meta, err := c.svc.Transform(reqCtx, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"data":   resp.GetData(),
-		"result": resp.GetResult(),
-		"error":  resp.GetError(),
-		"cached": fromCache,
-	})
+	httpcache.JSON(ctx, meta, httpcache.WithMeta(transformResult(resp), meta))
 }
 
 // Plan godoc
@@ -118,28 +189,40 @@ func (c *Controller) Transform(ctx *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        payload  body  PlanDTO  true  "Plan input"
+// @Param        Cache-Control  header  string  false  "no-cache | no-store | max-age=N"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200      {object}  map[string]any
+// @Success      304      "Not modified (If-None-Match matched the ETag)"
 // @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      422      {object}  map[string]string
+// @Failure      502      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Failure      504      {object}  map[string]string
 // @Router       /logic/plan [post]
 func (c *Controller) Plan(ctx *gin.Context) {
 	var req PlanDTO
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
 		return
 	}
-	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), 8*time.Second)
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), c.timeouts.Plan)
 	defer cancel()
 
-	resp, cached, err := c.svc.PlanTasks(reqCtx, req)
+	resp, meta, err := c.svc.PlanTasks(reqCtx, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "RPC failed: " + err.Error()})
+		rpcerr.Respond(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{
-		"tasks":  resp.GetTasks(),
-		"notes":  resp.GetNotes(),
-		"error":  resp.GetError(),
-		"cached": cached,
-	})
+	httpcache.JSON(ctx, meta, httpcache.WithMeta(planResult(resp), meta))
+}
+
+// Channel godoc
+// @Summary      LogicService channel state
+// @Description  Reports the connectivity state (IDLE/CONNECTING/READY/TRANSIENT_FAILURE/SHUTDOWN) of the shared gRPC channel
+// @Tags         logic
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Router       /logic/channel [get]
+func (c *Controller) Channel(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"state": c.svc.ChannelState()})
 }
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/httpcache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/jobs"
+)
+
+// JobOps exposes the logic operations to jobs and pipelines, keyed by the
+// route names ratelimit.RouteName gives their HTTP routes. These are not
+// always the cache prefixes (transform caches under logic:xform). Inputs are
+// the route bodies and results the route responses, cache fields included.
+func (s *Service) JobOps() map[string]jobs.Op {
+	return map[string]jobs.Op{
+		"logic:eval": jobs.Func(func(ctx context.Context, in EvalDTO) (any, error) {
+			resp, meta, err := s.Evaluate(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return httpcache.WithMeta(evalResult(resp), meta), nil
+		}),
+		"logic:transform": jobs.Func(func(ctx context.Context, in TransformDTO) (any, error) {
+			resp, meta, err := s.Transform(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			return httpcache.WithMeta(transformResult(resp), meta), nil
+		}),
+		"logic:plan": jobs.Func(func(ctx context.Context, in PlanDTO) (any, error) {
+			resp, meta, err := s.PlanTasks(ctx, in)
+			if err != nil {
+				return nil, err
+			}
+			// PlanTasks is unary, so tasks are streamed once the plan is back
+			for _, t := range resp.GetTasks() {
+				jobs.Report(ctx, "task", t)
+			}
+			return httpcache.WithMeta(planResult(resp), meta), nil
+		}),
+	}
+}
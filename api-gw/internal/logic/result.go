@@ -0,0 +1,27 @@
+package logic
+
+import (
+	"github.com/gin-gonic/gin"
+
+	lg "github.com/Patrick8894/harmonia/api-gw/gen/logic/v1"
+)
+
+// Result bodies shared by the synchronous handlers and background jobs.
+
+func evalResult(r *lg.EvalReply) gin.H {
+	return gin.H{"result": r.GetResult()}
+}
+
+func transformResult(r *lg.TransformReply) gin.H {
+	return gin.H{
+		"data":   r.GetData(),
+		"result": r.GetResult(),
+	}
+}
+
+func planResult(r *lg.PlanReply) gin.H {
+	return gin.H{
+		"tasks": r.GetTasks(),
+		"notes": r.GetNotes(),
+	}
+}
@@ -2,71 +2,122 @@ package logic
 
 import (
 	"context"
-	"time"
 
 	lg "github.com/Patrick8894/harmonia/api-gw/gen/logic/v1"
 	"github.com/Patrick8894/harmonia/api-gw/internal/cache"
+	"github.com/Patrick8894/harmonia/api-gw/internal/resilience"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
 )
 
 type Service struct {
-	c   *Client
-	kvs cache.Store
-	ttl time.Duration
+	c  *Client
+	ld *cache.Loader
 }
 
-func NewService(c *Client, kvs cache.Store, ttl time.Duration) *Service {
-	return &Service{c: c, kvs: kvs, ttl: ttl}
+func NewService(c *Client, ld *cache.Loader) *Service {
+	return &Service{c: c, ld: ld}
 }
 
 func (s *Service) Hello(ctx context.Context, name string) (string, error) {
 	return s.c.hello(ctx, name)
 }
 
-func (s *Service) Evaluate(ctx context.Context, in EvalDTO) (*lg.EvalReply, bool, error) {
-	key := cache.Key("logic:eval", in)
-	var cached lg.EvalReply
-	if ok, _ := s.kvs.Get(ctx, key, &cached); ok {
-		return &cached, true, nil
-	}
-	resp, err := s.c.evaluate(ctx, &lg.EvalRequest{
-		Expression: in.Expression,
-		Variables:  in.Variables,
-	})
-	if err != nil {
-		return nil, false, err
-	}
-	_ = s.kvs.Set(ctx, key, resp, s.ttl)
-	return resp, false, nil
+// Ping is the readiness probe for the logic service.
+func (s *Service) Ping(ctx context.Context) error {
+	return s.c.Ping(ctx)
+}
+
+// Breaker exposes the circuit breaker guarding the backend.
+func (s *Service) Breaker() *resilience.Breaker {
+	return s.c.Breaker()
+}
+
+// ChannelState reports the connectivity state of the shared gRPC channel.
+func (s *Service) ChannelState() string {
+	return s.c.State().String()
+}
+
+func (s *Service) Evaluate(ctx context.Context, in EvalDTO) (*lg.EvalReply, cache.Meta, error) {
+	key := evalKey(in)
+	return checked(cache.Load(ctx, s.ld, key, func(ctx context.Context) (*lg.EvalReply, error) {
+		return s.c.evaluate(ctx, &lg.EvalRequest{
+			Expression: in.Expression,
+			Variables:  in.Variables,
+		})
+	}))
 }
 
-func (s *Service) Transform(ctx context.Context, in TransformDTO) (*lg.TransformReply, bool, error) {
-	key := cache.Key("logic:xform", in)
-	var cached lg.TransformReply
-	if ok, _ := s.kvs.Get(ctx, key, &cached); ok {
-		return &cached, true, nil
+func (s *Service) Transform(ctx context.Context, in TransformDTO) (*lg.TransformReply, cache.Meta, error) {
+	key := transformKey(in)
+	return checked(cache.Load(ctx, s.ld, key, func(ctx context.Context) (*lg.TransformReply, error) {
+		return s.c.transform(ctx, &lg.TransformRequest{
+			Data: in.Data, Expr: in.Expr, VarName: in.VarName, Op: parseTransformOp(in.Op),
+		})
+	}))
+}
+
+func (s *Service) PlanTasks(ctx context.Context, in PlanDTO) (*lg.PlanReply, cache.Meta, error) {
+	key := planKey(in)
+	return checked(cache.Load(ctx, s.ld, key, func(ctx context.Context) (*lg.PlanReply, error) {
+		return s.c.planTasks(ctx, &lg.PlanRequest{
+			Goal: in.Goal, Hints: in.Hints, MaxSteps: in.MaxSteps,
+		})
+	}))
+}
+
+// checked turns the error field of a (possibly cached) reply into an
+// rpcerr.Invalid error, so callers never mistake it for a success.
+func checked[R interface{ GetError() string }](resp R, meta cache.Meta, err error) (R, cache.Meta, error) {
+	if err == nil && resp.GetError() != "" {
+		err = rpcerr.Domain("logic", resp.GetError())
 	}
-	resp, err := s.c.transform(ctx, &lg.TransformRequest{
-		Data: in.Data, Expr: in.Expr, VarName: in.VarName, Op: parseTransformOp(in.Op),
-	})
-	if err != nil {
-		return nil, false, err
+	return resp, meta, err
+}
+
+// cacheSchema versions the cached logic replies; bump it whenever a reply
+// message in logic.proto changes incompatibly.
+const cacheSchema = "v1"
+
+func evalKey(in EvalDTO) string           { return cache.Key("logic:eval", cacheSchema, in) }
+func transformKey(in TransformDTO) string { return cache.Key("logic:xform", cacheSchema, in) }
+func planKey(in PlanDTO) string           { return cache.Key("logic:plan", cacheSchema, in) }
+
+// CacheRoutes describes each cached logic route, so a cache entry can be
+// located and decoded from the request body that produced it.
+func CacheRoutes() map[string]cache.RouteSpec {
+	return map[string]cache.RouteSpec{
+		"logic:eval": {
+			Key:   cache.BodyKey(evalKey),
+			Reply: func() any { return new(lg.EvalReply) },
+		},
+		"logic:xform": {
+			Key:   cache.BodyKey(transformKey),
+			Reply: func() any { return new(lg.TransformReply) },
+		},
+		"logic:plan": {
+			Key:   cache.BodyKey(planKey),
+			Reply: func() any { return new(lg.PlanReply) },
+		},
 	}
-	_ = s.kvs.Set(ctx, key, resp, s.ttl)
-	return resp, false, nil
 }
 
-func (s *Service) PlanTasks(ctx context.Context, in PlanDTO) (*lg.PlanReply, bool, error) {
-	key := cache.Key("logic:plan", in)
-	var cached lg.PlanReply
-	if ok, _ := s.kvs.Get(ctx, key, &cached); ok {
-		return &cached, true, nil
+// EvaluateBatch evaluates every input: hits come from one bulk cache
+// lookup and at most limit misses reach the logic service at once.
+func (s *Service) EvaluateBatch(ctx context.Context, ins []EvalDTO, limit int) []cache.Item[lg.EvalReply] {
+	keys := make([]string, len(ins))
+	for i, in := range ins {
+		keys[i] = evalKey(in)
 	}
-	resp, err := s.c.planTasks(ctx, &lg.PlanRequest{
-		Goal: in.Goal, Hints: in.Hints, MaxSteps: in.MaxSteps,
+	items := cache.LoadMany(ctx, s.ld, keys, limit, func(ctx context.Context, i int) (*lg.EvalReply, error) {
+		return s.c.evaluate(ctx, &lg.EvalRequest{
+			Expression: ins[i].Expression,
+			Variables:  ins[i].Variables,
+		})
 	})
-	if err != nil {
-		return nil, false, err
+	for i, it := range items {
+		if it.Err == nil && it.Value.GetError() != "" {
+			items[i].Err = rpcerr.Domain("logic", it.Value.GetError())
+		}
 	}
-	_ = s.kvs.Set(ctx, key, resp, s.ttl)
-	return resp, false, nil
+	return items
 }
@@ -0,0 +1,33 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/ratelimit"
+)
+
+// Cost charges a pipeline the sum of its steps' costs, priced on their
+// static inputs (wired-in data is not known up front).
+func Cost(costs map[string]ratelimit.CostFunc) ratelimit.CostFunc {
+	return func(body []byte) (float64, error) {
+		var p PipelineDTO
+		if err := json.Unmarshal(body, &p); err != nil {
+			return 0, err
+		}
+		var total float64
+		for _, s := range p.Steps {
+			fn, ok := costs[strings.Replace(s.Op, ".", ":", 1)]
+			if !ok {
+				total++
+				continue
+			}
+			c, err := fn(s.Input)
+			if err != nil || c < 1 {
+				c = 1
+			}
+			total += c
+		}
+		return total, nil
+	}
+}
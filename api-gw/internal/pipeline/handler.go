@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+)
+
+type Controller struct {
+	run     *Runner
+	timeout time.Duration
+}
+
+// NewController serves pipelines with an overall deadline of timeout.
+func NewController(run *Runner, timeout time.Duration) *Controller {
+	return &Controller{run: run, timeout: timeout}
+}
+
+func Register(rg *gin.RouterGroup, ctrl *Controller) {
+	rg.POST("/pipeline", ctrl.Run)
+}
+
+// Run godoc
+// @Summary      Run a pipeline of logic and engine steps
+// @Description  Runs declarative steps, wiring earlier results into later inputs ("wire": {"data": "square.data"}); independent steps run concurrently and each is cached like its own route. Step failures are reported per step.
+// @Tags         pipeline
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  PipelineDTO  true  "Steps"
+// @Success      200      {object}  Report
+// @Failure      400      {object}  map[string]string
+// @Router       /pipeline [post]
+func (c *Controller) Run(ctx *gin.Context) {
+	var req PipelineDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "request_id": requestid.Get(ctx)})
+		return
+	}
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), c.timeout)
+	defer cancel()
+
+	rep, err := c.run.Run(reqCtx, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "request_id": requestid.Get(ctx)})
+		return
+	}
+	ctx.JSON(http.StatusOK, rep)
+}
@@ -0,0 +1,311 @@
+// Package pipeline runs a declarative graph of engine and logic steps in one
+// request: each step names an operation, a static input and wires that copy
+// fields of earlier steps' results into that input. Steps run as soon as the
+// steps they depend on have finished, so independent branches run
+// concurrently. Every step goes through the service layer and so through
+// the result cache.
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/jobs"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+type StepDTO struct {
+	ID    string          `json:"id"    binding:"required"`
+	Op    string          `json:"op"    binding:"required"` // e.g. "logic:transform" (or "logic.transform")
+	Input json.RawMessage `json:"input"`                    // the body the synchronous route takes
+	// Wire maps an input field path to "<step id>.<result path>", e.g.
+	// {"data": "square.data"} or {"variables.x": "first.result"}.
+	Wire      map[string]string `json:"wire"`
+	TimeoutMS int               `json:"timeout_ms"` // optional per-step deadline
+}
+
+type PipelineDTO struct {
+	Steps []StepDTO `json:"steps" binding:"required,min=1,dive"`
+}
+
+// StepResult is the outcome of one step, in request order.
+type StepResult struct {
+	ID      string          `json:"id"`
+	Op      string          `json:"op"`
+	Status  string          `json:"status"` // succeeded | failed | skipped
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Kind    string          `json:"kind,omitempty"`
+	StartMS float64         `json:"start_ms"` // offset from the start of the pipeline
+	TookMS  float64         `json:"took_ms"`
+}
+
+type Report struct {
+	OK     bool         `json:"ok"`
+	TookMS float64      `json:"took_ms"`
+	Steps  []StepResult `json:"steps"`
+}
+
+// Runner executes pipelines over a registry of operations.
+type Runner struct {
+	ops      map[string]jobs.Op
+	maxSteps int
+}
+
+func NewRunner(ops map[string]jobs.Op, maxSteps int) *Runner {
+	if maxSteps <= 0 {
+		maxSteps = 32
+	}
+	return &Runner{ops: ops, maxSteps: maxSteps}
+}
+
+// wire is one resolved input wiring.
+type wire struct {
+	field []string // path in this step's input
+	from  string   // step ID
+	path  []string // path in that step's result
+}
+
+type node struct {
+	StepDTO
+	op    jobs.Op
+	wires []wire
+	deps  []string
+}
+
+// plan checks the pipeline and resolves its wiring; errors are the caller's.
+func (r *Runner) plan(p PipelineDTO) ([]*node, error) {
+	if len(p.Steps) > r.maxSteps {
+		return nil, fmt.Errorf("pipeline has %d steps; the limit is %d", len(p.Steps), r.maxSteps)
+	}
+	byID := make(map[string]*node, len(p.Steps))
+	nodes := make([]*node, len(p.Steps))
+	for i, s := range p.Steps {
+		if _, dup := byID[s.ID]; dup {
+			return nil, fmt.Errorf("duplicate step id %q", s.ID)
+		}
+		s.Op = strings.Replace(s.Op, ".", ":", 1)
+		op, ok := r.ops[s.Op]
+		if !ok {
+			return nil, fmt.Errorf("step %q: unknown op %q", s.ID, s.Op)
+		}
+		n := &node{StepDTO: s, op: op}
+		nodes[i], byID[s.ID] = n, n
+	}
+	for _, n := range nodes {
+		seen := map[string]bool{}
+		for field, src := range n.Wire {
+			from, path, _ := strings.Cut(src, ".")
+			if _, ok := byID[from]; !ok || from == n.ID {
+				return nil, fmt.Errorf("step %q: wire %q refers to unknown step %q", n.ID, field, from)
+			}
+			w := wire{field: strings.Split(field, "."), from: from}
+			if path != "" {
+				w.path = strings.Split(path, ".")
+			}
+			n.wires = append(n.wires, w)
+			if !seen[from] {
+				seen[from] = true
+				n.deps = append(n.deps, from)
+			}
+		}
+	}
+	if cyc := findCycle(nodes, byID); cyc != "" {
+		return nil, fmt.Errorf("steps form a cycle through %q", cyc)
+	}
+	return nodes, nil
+}
+
+func findCycle(nodes []*node, byID map[string]*node) string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var visit func(id string) string
+	visit = func(id string) string {
+		switch state[id] {
+		case visiting:
+			return id
+		case done:
+			return ""
+		}
+		state[id] = visiting
+		for _, d := range byID[id].deps {
+			if c := visit(d); c != "" {
+				return c
+			}
+		}
+		state[id] = done
+		return ""
+	}
+	for _, n := range nodes {
+		if c := visit(n.ID); c != "" {
+			return c
+		}
+	}
+	return ""
+}
+
+// Run executes every step once its dependencies have succeeded; steps
+// whose dependencies failed are skipped. It only fails for an invalid
+// pipeline.
+func (r *Runner) Run(ctx context.Context, p PipelineDTO) (*Report, error) {
+	nodes, err := r.plan(p)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	results := make([]StepResult, len(nodes))
+	outputs := make(map[string]any, len(nodes)) // decoded results of succeeded steps
+	done := make(map[string]chan struct{}, len(nodes))
+	for _, n := range nodes {
+		done[n.ID] = make(chan struct{})
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[n.ID])
+			res := StepResult{ID: n.ID, Op: n.Op}
+			for _, d := range n.deps {
+				<-done[d]
+			}
+
+			mu.Lock()
+			for _, d := range n.deps {
+				if _, ok := outputs[d]; !ok {
+					mu.Unlock()
+					res.Status, res.Error = "skipped", fmt.Sprintf("dependency %q did not succeed", d)
+					results[i] = res
+					return
+				}
+			}
+			input, err := n.resolve(outputs)
+			mu.Unlock()
+			if err != nil {
+				res.Status, res.Error, res.Kind = "failed", err.Error(), rpcerr.Invalid.String()
+				results[i] = res
+				return
+			}
+
+			sctx := ctx
+			if n.TimeoutMS > 0 {
+				var cancel context.CancelFunc
+				sctx, cancel = context.WithTimeout(ctx, time.Duration(n.TimeoutMS)*time.Millisecond)
+				defer cancel()
+			}
+			t0 := time.Now()
+			res.StartMS = ms(t0.Sub(start))
+			out, err := n.op.Run(sctx, input)
+			res.TookMS = ms(time.Since(t0))
+			var b []byte
+			if err == nil {
+				b, err = json.Marshal(out)
+			}
+			if err != nil {
+				res.Status, res.Error, res.Kind = "failed", err.Error(), errorKind(err)
+				results[i] = res
+				return
+			}
+			res.Status, res.Result = "succeeded", b
+			var decoded any
+			_ = json.Unmarshal(b, &decoded)
+			mu.Lock()
+			outputs[n.ID] = decoded
+			mu.Unlock()
+			results[i] = res
+		}()
+	}
+	wg.Wait()
+
+	rep := &Report{OK: true, TookMS: ms(time.Since(start)), Steps: results}
+	for _, s := range results {
+		if s.Status != "succeeded" {
+			rep.OK = false
+		}
+	}
+	return rep, nil
+}
+
+// resolve copies wired values into the step's input; every dependency
+// must have an output.
+func (n *node) resolve(outputs map[string]any) (json.RawMessage, error) {
+	if len(n.wires) == 0 {
+		if len(n.Input) == 0 {
+			return json.RawMessage("{}"), nil
+		}
+		return n.Input, nil
+	}
+	input := map[string]any{}
+	if len(n.Input) > 0 {
+		if err := json.Unmarshal(n.Input, &input); err != nil {
+			return nil, fmt.Errorf("input must be a JSON object to take wires: %w", err)
+		}
+	}
+	for _, w := range n.wires {
+		v, err := lookup(outputs[w.from], w.path)
+		if err != nil {
+			return nil, fmt.Errorf("wire from %q: %w", w.from, err)
+		}
+		set(input, w.field, v)
+	}
+	return json.Marshal(input)
+}
+
+func lookup(v any, path []string) (any, error) {
+	for i, p := range path {
+		switch x := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = x[p]; !ok {
+				return nil, fmt.Errorf("no field %q", strings.Join(path[:i+1], "."))
+			}
+		case []any:
+			idx, err := strconv.Atoi(p)
+			if err != nil || idx < 0 || idx >= len(x) {
+				return nil, fmt.Errorf("no element %q", strings.Join(path[:i+1], "."))
+			}
+			v = x[idx]
+		default:
+			return nil, fmt.Errorf("%q is not an object or array", strings.Join(path[:i], "."))
+		}
+	}
+	return v, nil
+}
+
+func set(m map[string]any, path []string, v any) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[p] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = v
+}
+
+// errorKind names the failure like rpcerr does; input the operation
+// rejects after wiring counts as invalid input.
+func errorKind(err error) string {
+	var re *rpcerr.Error
+	if errors.As(err, &re) {
+		return re.Kind.String()
+	}
+	if k := rpcerr.KindOf(err); k != rpcerr.Internal {
+		return k.String()
+	}
+	return rpcerr.Invalid.String()
+}
+
+func ms(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
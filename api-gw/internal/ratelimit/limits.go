@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rule is a token bucket: it refills at Rate tokens per second up to Burst.
+// The zero Rule means unlimited.
+type Rule struct {
+	Rate  float64
+	Burst float64
+}
+
+func (r Rule) Unlimited() bool { return r.Rate <= 0 || r.Burst <= 0 }
+
+// PerWindow builds a rule allowing n per window, all of which may be spent
+// at once.
+func PerWindow(n float64, window time.Duration) Rule {
+	if n <= 0 || window <= 0 {
+		return Rule{}
+	}
+	return Rule{Rate: n / window.Seconds(), Burst: n}
+}
+
+// Limits maps a route name (e.g. "engine:matmul", see RouteName) to its rule.
+type Limits struct {
+	Default Rule
+	Routes  map[string]Rule
+}
+
+func (l Limits) For(route string) Rule {
+	if r, ok := l.Routes[route]; ok {
+		return r
+	}
+	return l.Default
+}
+
+// ParseLimits reads a comma-separated list of route=count/period[+burst]
+// rules, for example "default=120/m,auth:login=5/m+3,engine:matmul=10/1m".
+// period is a duration or a bare unit (s, m, h); burst defaults to count.
+// "default" sets the rule for routes without their own.
+func ParseLimits(spec string) (Limits, error) {
+	l := Limits{Routes: map[string]Rule{}}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		route, val, ok := strings.Cut(item, "=")
+		if !ok {
+			return l, fmt.Errorf("rate limit %q: want route=count/period[+burst]", item)
+		}
+		val, burstStr, hasBurst := strings.Cut(val, "+")
+		countStr, periodStr, ok := strings.Cut(val, "/")
+		if !ok {
+			return l, fmt.Errorf("rate limit %q: want route=count/period[+burst]", item)
+		}
+		count, err := strconv.ParseFloat(strings.TrimSpace(countStr), 64)
+		if err != nil || count < 0 {
+			return l, fmt.Errorf("rate limit %q: bad count", item)
+		}
+		period, err := parsePeriod(strings.TrimSpace(periodStr))
+		if err != nil {
+			return l, fmt.Errorf("rate limit %q: %w", item, err)
+		}
+		rule := PerWindow(count, period)
+		if hasBurst {
+			if rule.Burst, err = strconv.ParseFloat(strings.TrimSpace(burstStr), 64); err != nil {
+				return l, fmt.Errorf("rate limit %q: bad burst", item)
+			}
+		}
+		if route = strings.TrimSpace(route); route == "default" {
+			l.Default = rule
+		} else {
+			l.Routes[route] = rule
+		}
+	}
+	return l, nil
+}
+
+func parsePeriod(s string) (time.Duration, error) {
+	switch s {
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// RouteName turns a gin route template into the name used in limits:
+// "/api/engine/matmul" becomes "engine:matmul".
+func RouteName(fullPath string) string {
+	return strings.ReplaceAll(strings.Trim(strings.TrimPrefix(fullPath, "/api"), "/"), "/", ":")
+}
+
+// Route names the route c matched, as RouteName does, with custom verbs
+// spelled out: a parameter inside a path segment ("/stats:verb", see
+// batch.Suffix) is replaced by what the request sent, so POST
+// /api/engine/stats:batch is "engine:stats:batch". Limits, costs and the
+// history all name routes this way.
+func Route(c *gin.Context) string {
+	route := c.FullPath()
+	for _, p := range c.Params {
+		if i := strings.Index(route, ":"+p.Key); i > 0 && route[i-1] != '/' {
+			route = route[:i] + p.Value + route[i+1+len(p.Key):]
+		}
+	}
+	return RouteName(route)
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Limits
+		wantErr bool
+	}{
+		{"empty", "", Limits{Routes: map[string]Rule{}}, false},
+		{"default and routes", "default=120/m,auth:login=5/m+3,engine:matmul=10/1m", Limits{
+			Default: Rule{Rate: 2, Burst: 120},
+			Routes: map[string]Rule{
+				"auth:login":    {Rate: 5.0 / 60, Burst: 3},
+				"engine:matmul": {Rate: 10.0 / 60, Burst: 10},
+			},
+		}, false},
+		{"bare units", "a=1/s,b=3600/h", Limits{Routes: map[string]Rule{
+			"a": {Rate: 1, Burst: 1},
+			"b": {Rate: 1, Burst: 3600},
+		}}, false},
+		{"duration period", "a=30/30s", Limits{Routes: map[string]Rule{"a": {Rate: 1, Burst: 30}}}, false},
+		{"fractional count", "a=0.5/s", Limits{Routes: map[string]Rule{"a": {Rate: 0.5, Burst: 0.5}}}, false},
+		{"zero count is unlimited", "a=0/m", Limits{Routes: map[string]Rule{"a": {}}}, false},
+		{"spaces and empty items", " a = 2 / s + 4 , ,", Limits{Routes: map[string]Rule{"a": {Rate: 2, Burst: 4}}}, false},
+		{"missing =", "engine:matmul", Limits{}, true},
+		{"missing period", "a=10", Limits{}, true},
+		{"bad count", "a=ten/m", Limits{}, true},
+		{"negative count", "a=-1/m", Limits{}, true},
+		{"bad period", "a=10/fortnight", Limits{}, true},
+		{"bad burst", "a=10/m+lots", Limits{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimits(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	l, err := ParseLimits("default=60/m,engine:matmul=6/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.For("engine:matmul"); got != (Rule{Rate: 0.1, Burst: 6}) {
+		t.Errorf("engine:matmul = %+v", got)
+	}
+	if got := l.For("engine:stats"); got != l.Default {
+		t.Errorf("engine:stats = %+v, want the default %+v", got, l.Default)
+	}
+	if !(Limits{}).For("x").Unlimited() {
+		t.Error("empty limits should be unlimited")
+	}
+}
+
+func TestPerWindow(t *testing.T) {
+	tests := []struct {
+		n      float64
+		window time.Duration
+		want   Rule
+	}{
+		{60, time.Minute, Rule{Rate: 1, Burst: 60}},
+		{5, 10 * time.Second, Rule{Rate: 0.5, Burst: 5}},
+		{0, time.Minute, Rule{}},
+		{10, 0, Rule{}},
+	}
+	for _, tt := range tests {
+		if got := PerWindow(tt.n, tt.window); got != tt.want {
+			t.Errorf("PerWindow(%v, %v) = %+v, want %+v", tt.n, tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestRouteName(t *testing.T) {
+	tests := map[string]string{
+		"/api/engine/matmul":    "engine:matmul",
+		"/api/auth/login":       "auth:login",
+		"/metrics":              "metrics",
+		"/api":                  "",
+		"/api/logic/transform/": "logic:transform",
+	}
+	for in, want := range tests {
+		if got := RouteName(in); got != want {
+			t.Errorf("RouteName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var got string
+	h := func(c *gin.Context) { got = Route(c) }
+	r.POST("/api/engine/stats", h)
+	r.POST("/api/engine/stats:verb", h)
+	r.GET("/api/jobs/:id", h)
+	r.GET("/api/jobs/:id/events", h)
+
+	tests := []struct {
+		method, path, want string
+	}{
+		{http.MethodPost, "/api/engine/stats", "engine:stats"},
+		{http.MethodPost, "/api/engine/stats:batch", "engine:stats:batch"},
+		{http.MethodPost, "/api/engine/stats:other", "engine:stats:other"},
+		{http.MethodGet, "/api/jobs/abc", "jobs::id"},
+		{http.MethodGet, "/api/jobs/abc/events", "jobs::id:events"},
+	}
+	for _, tt := range tests {
+		got = ""
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+		if got != tt.want {
+			t.Errorf("%s %s: Route = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rule   Rule
+}
+
+// MemoryStore keeps buckets in process; limits are per gateway instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, rule Rule, cost float64) (Result, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rule.Burst, last: now}
+		m.buckets[key] = b
+	}
+	b.rule = rule
+	b.tokens = math.Min(rule.Burst, b.tokens+now.Sub(b.last).Seconds()*rule.Rate)
+	b.last = now
+	allowed := b.tokens >= cost
+	if allowed {
+		b.tokens = math.Min(rule.Burst, b.tokens-cost)
+	}
+
+	if m.takes++; m.takes%1024 == 0 {
+		m.sweepLocked(now)
+	}
+	return result(rule, cost, b.tokens, allowed), nil
+}
+
+// sweepLocked forgets buckets that have refilled completely; a fresh bucket
+// is indistinguishable from them.
+func (m *MemoryStore) sweepLocked(now time.Time) {
+	for k, b := range m.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rule.Rate >= b.rule.Burst {
+			delete(m.buckets, k)
+		}
+	}
+}
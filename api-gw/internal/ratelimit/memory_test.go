@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	rule := Rule{Rate: 2, Burst: 4} // 2 tokens/s, up to 4
+
+	type step struct {
+		elapsed   time.Duration // moved off the bucket clock before the take
+		cost      float64
+		allowed   bool
+		remaining float64
+		retry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"burst then deny", []step{
+			{0, 1, true, 3, 0},
+			{0, 2, true, 1, 0},
+			{0, 1, true, 0, 0},
+			{0, 1, false, 0, 500 * time.Millisecond},
+		}},
+		{"refills at rate", []step{
+			{0, 4, true, 0, 0},
+			{time.Second, 1, true, 1, 0},
+			{250 * time.Millisecond, 2, false, 1.5, 250 * time.Millisecond},
+		}},
+		{"refill caps at burst", []step{
+			{0, 1, true, 3, 0},
+			{time.Hour, 1, true, 3, 0},
+		}},
+		{"denied take spends nothing", []step{
+			{0, 3, true, 1, 0},
+			{0, 2, false, 1, 500 * time.Millisecond},
+			{0, 1, true, 0, 0},
+		}},
+		{"cost above burst never fits", []step{
+			{0, 5, false, 4, 500 * time.Millisecond},
+			{time.Hour, 5, false, 4, 500 * time.Millisecond},
+		}},
+		{"zero cost only reads", []step{
+			{0, 0, true, 4, 0},
+		}},
+		{"negative cost refunds up to burst", []step{
+			{0, 3, true, 1, 0},
+			{0, -1, true, 2, 0},
+			{0, -5, true, 4, 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemoryStore()
+			for i, s := range tt.steps {
+				if b, ok := m.buckets["k"]; ok {
+					b.last = b.last.Add(-s.elapsed)
+				}
+				res, err := m.Take(context.Background(), "k", rule, s.cost)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.Allowed != s.allowed || !near(res.Remaining, s.remaining) || !nearDur(res.RetryAfter, s.retry) {
+					t.Fatalf("step %d: got allowed=%v remaining=%v retry=%v, want %v %v %v",
+						i, res.Allowed, res.Remaining, res.RetryAfter, s.allowed, s.remaining, s.retry)
+				}
+				if res.Limit != rule.Burst {
+					t.Fatalf("step %d: limit = %v, want %v", i, res.Limit, rule.Burst)
+				}
+				if want := time.Duration((rule.Burst - res.Remaining) / rule.Rate * float64(time.Second)); !nearDur(res.ResetAfter, want) {
+					t.Fatalf("step %d: reset after %v, want %v", i, res.ResetAfter, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	m := NewMemoryStore()
+	rule := Rule{Rate: 1, Burst: 1}
+	ctx := context.Background()
+	if res, _ := m.Take(ctx, "a", rule, 1); !res.Allowed {
+		t.Fatal("a: first take denied")
+	}
+	if res, _ := m.Take(ctx, "a", rule, 1); res.Allowed {
+		t.Fatal("a: second take allowed")
+	}
+	if res, _ := m.Take(ctx, "b", rule, 1); !res.Allowed {
+		t.Fatal("b: shares a's bucket")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	m := NewMemoryStore()
+	rule := Rule{Rate: 1, Burst: 2}
+	ctx := context.Background()
+	m.Take(ctx, "full", rule, 1)
+	m.Take(ctx, "spent", rule, 2)
+	m.buckets["full"].last = m.buckets["full"].last.Add(-time.Second)
+
+	m.mu.Lock()
+	m.sweepLocked(time.Now())
+	m.mu.Unlock()
+	if _, ok := m.buckets["full"]; ok {
+		t.Error("refilled bucket was kept")
+	}
+	if _, ok := m.buckets["spent"]; !ok {
+		t.Error("partly spent bucket was dropped")
+	}
+}
+
+// The store reads the wall clock between steps; allow for the drift.
+func near(a, b float64) bool { return a-b < 0.01 && b-a < 0.01 }
+
+func nearDur(a, b time.Duration) bool { return a-b < 10*time.Millisecond && b-a < 10*time.Millisecond }
@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+	"github.com/Patrick8894/harmonia/api-gw/internal/logging"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+)
+
+var rejections = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "harmonia_ratelimit_rejections_total",
+	Help: "Requests rejected by rate limits or compute quotas.",
+}, []string{"policy", "route", "reason"})
+
+// CostFunc prices a request body in compute units.
+type CostFunc func(body []byte) (float64, error)
+
+// BodyCost decodes the JSON body as T and prices it with fn.
+func BodyCost[T any](fn func(T) float64) CostFunc {
+	return func(body []byte) (float64, error) {
+		var v T
+		if err := json.Unmarshal(body, &v); err != nil {
+			return 0, err
+		}
+		return fn(v), nil
+	}
+}
+
+// Policy says whom requests are counted against and how much they may do.
+type Policy struct {
+	Name   string                      // "user" | "ip"; part of every bucket key
+	Key    func(c *gin.Context) string // caller identity; "" skips limiting
+	Limits Limits
+	Quota  Rule                // compute units; zero means no quota
+	Costs  map[string]CostFunc // per route; requests on other routes cost 1 unit
+}
+
+// ByUser keys on the session user set by auth.Middleware.
+func ByUser(c *gin.Context) string { return c.GetString(auth.CtxUserKey) }
+
+// ByIP keys on the client address, for routes used before login.
+func ByIP(c *gin.Context) string { return c.ClientIP() }
+
+// Limiter builds the gateway's rate-limit middleware. A nil *Limiter
+// limits nothing.
+type Limiter struct {
+	Store Store
+	User  Policy
+	IP    Policy
+}
+
+// PerUser limits authenticated routes; mount it after auth.RequireAuth.
+func (l *Limiter) PerUser() gin.HandlerFunc {
+	if l == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return Middleware(l.Store, l.User)
+}
+
+// PerIP limits anonymous routes such as login and registration.
+func (l *Limiter) PerIP() gin.HandlerFunc {
+	if l == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return Middleware(l.Store, l.IP)
+}
+
+// Middleware takes one token from the route's bucket and the request's cost
+// from the quota, answering 429 when either is short; the route token goes
+// back when the quota refuses, so an exhausted quota doesn't also drain the
+// request rate. X-RateLimit-* headers report the route bucket and
+// X-RateLimit-Units-* the quota. If the store is unreachable requests are
+// let through.
+func Middleware(store Store, p Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := p.Key(c)
+		if id == "" {
+			c.Next()
+			return
+		}
+		route := Route(c)
+		ctx := c.Request.Context()
+
+		rule, routeKey, took := p.Limits.For(route), p.Name+":"+route+":"+id, false
+		if !rule.Unlimited() {
+			res, err := store.Take(ctx, routeKey, rule, 1)
+			if err != nil {
+				slog.WarnContext(ctx, "rate limit store failed; allowing request", "policy", p.Name, "err", err)
+			} else {
+				setHeaders(c.Writer.Header(), "X-RateLimit-", res)
+				if !res.Allowed {
+					reject(c, p.Name, route, "rate_limited", "rate limit exceeded", res)
+					return
+				}
+				took = true
+			}
+		}
+
+		if !p.Quota.Unlimited() {
+			cost := requestCost(c, p.Costs[route])
+			res, err := store.Take(ctx, p.Name+":units:"+id, p.Quota, cost)
+			if err != nil {
+				slog.WarnContext(ctx, "rate limit store failed; allowing request", "policy", p.Name, "err", err)
+			} else {
+				h := c.Writer.Header()
+				setHeaders(h, "X-RateLimit-Units-", res)
+				h.Set("X-RateLimit-Units-Cost", formatUnits(cost))
+				logging.Annotate(ctx, slog.Float64("units", cost))
+				if !res.Allowed {
+					msg := "compute quota exceeded"
+					if cost > p.Quota.Burst {
+						msg = "request costs more compute units than the quota allows"
+					}
+					if took {
+						if back, err := store.Take(ctx, routeKey, rule, -1); err == nil {
+							setHeaders(h, "X-RateLimit-", back)
+						}
+					}
+					reject(c, p.Name, route, "quota_exceeded", msg, res)
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// requestCost prices the body with fn and puts it back for the handler.
+// Bodies that don't decode cost 1; the handler rejects them anyway.
+func requestCost(c *gin.Context, fn CostFunc) float64 {
+	if fn == nil || c.Request.Body == nil {
+		return 1
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 1
+	}
+	cost, err := fn(body)
+	if err != nil || cost < 1 {
+		return 1
+	}
+	return cost
+}
+
+func setHeaders(h http.Header, prefix string, res Result) {
+	h.Set(prefix+"Limit", formatUnits(res.Limit))
+	h.Set(prefix+"Remaining", formatUnits(math.Floor(res.Remaining)))
+	h.Set(prefix+"Reset", strconv.FormatInt(ceilSeconds(res.ResetAfter), 10))
+}
+
+func reject(c *gin.Context, policy, route, reason, msg string, res Result) {
+	rejections.WithLabelValues(policy, route, reason).Inc()
+	if res.RetryAfter > 0 && res.RetryAfter < 365*24*time.Hour {
+		c.Header("Retry-After", strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+	}
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":      msg,
+		"kind":       reason,
+		"request_id": requestid.Get(c),
+	})
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+func formatUnits(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
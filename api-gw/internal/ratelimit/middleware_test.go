@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddlewareQuotaRefusalKeepsRouteToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := Policy{
+		Name:   "user",
+		Key:    func(*gin.Context) string { return "u" },
+		Limits: Limits{Default: PerWindow(3, time.Hour)},
+		Quota:  PerWindow(2, time.Hour),
+		Costs: map[string]CostFunc{
+			"engine:stats": BodyCost(func(v struct{ N float64 }) float64 { return v.N }),
+		},
+	}
+	r := gin.New()
+	r.POST("/api/engine/stats", Middleware(NewMemoryStore(), p), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		body          string
+		code          int
+		routeLeft     string
+		unitsLeft     string
+		wantRejection string
+	}{
+		{`{"n": 1}`, http.StatusOK, "2", "1", ""},
+		{`{"n": 2}`, http.StatusTooManyRequests, "2", "1", "quota_exceeded"},
+		{`{"n": 2}`, http.StatusTooManyRequests, "2", "1", "quota_exceeded"},
+		{`{"n": 1}`, http.StatusOK, "1", "0", ""},
+		{`{"n": 1}`, http.StatusTooManyRequests, "1", "0", "quota_exceeded"},
+		{`{"n": 1}`, http.StatusTooManyRequests, "1", "0", "quota_exceeded"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/engine/stats", strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Fatalf("request %d: status %d, want %d", i, w.Code, tt.code)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != tt.routeLeft {
+			t.Errorf("request %d: route remaining %q, want %q", i, got, tt.routeLeft)
+		}
+		if got := w.Header().Get("X-RateLimit-Units-Remaining"); got != tt.unitsLeft {
+			t.Errorf("request %d: units remaining %q, want %q", i, got, tt.unitsLeft)
+		}
+		if tt.wantRejection != "" && !strings.Contains(w.Body.String(), tt.wantRejection) {
+			t.Errorf("request %d: body %s, want kind %q", i, w.Body.String(), tt.wantRejection)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and debits one bucket atomically, using the Redis clock
+// so that every gateway instance agrees on time. The hash expires once the
+// bucket would be full again.
+var takeScript = redis.NewScript(`
+local rate  = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost  = tonumber(ARGV[3])
+local t     = redis.call('TIME')
+local now   = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local h = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(h[1]) or burst
+local ts = tonumber(h[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+
+local allowed = 0
+if tokens >= cost then
+  tokens = math.min(burst, tokens - cost)
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore shares buckets between gateway instances.
+type RedisStore struct {
+	rdb   *redis.Client
+	keyNS string
+}
+
+func NewRedisStore(rdb *redis.Client, ns string) *RedisStore {
+	return &RedisStore{rdb: rdb, keyNS: ns}
+}
+
+func (r *RedisStore) Take(ctx context.Context, key string, rule Rule, cost float64) (Result, error) {
+	args := []any{
+		strconv.FormatFloat(rule.Rate, 'g', -1, 64),
+		strconv.FormatFloat(rule.Burst, 'g', -1, 64),
+		strconv.FormatFloat(cost, 'g', -1, 64),
+	}
+	vals, err := takeScript.Run(ctx, r.rdb, []string{r.keyNS + key}, args...).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	allowed, _ := vals[0].(int64)
+	s, _ := vals[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(rule, cost, tokens, allowed == 1), nil
+}
@@ -0,0 +1,45 @@
+// Package ratelimit throttles requests with token buckets kept in memory or
+// in Redis: one bucket per route and caller, plus a compute-unit quota that
+// each request is charged against according to the work it asks for.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Store debits cost tokens from the bucket at key, creating it full. A
+// negative cost gives tokens back, up to the burst.
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule, cost float64) (Result, error)
+}
+
+// Result describes a bucket after a Take.
+type Result struct {
+	Allowed    bool
+	Limit      float64
+	Remaining  float64
+	RetryAfter time.Duration // until cost tokens are available; zero when allowed
+	ResetAfter time.Duration // until the bucket is full again
+}
+
+func result(rule Rule, cost, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:    allowed,
+		Limit:      rule.Burst,
+		Remaining:  math.Max(0, tokens),
+		ResetAfter: seconds((rule.Burst - tokens) / rule.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((cost - tokens) / rule.Rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,71 @@
+// Package requestid assigns every request an ID, taken from X-Request-ID
+// when the caller sends a sane one, and makes it available to handlers,
+// logs, error bodies and downstream RPCs.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	Header = "X-Request-ID"
+	// MetadataKey carries the ID in outgoing gRPC metadata.
+	MetadataKey = "x-request-id"
+	// CtxKey is where the ID lives in the gin context.
+	CtxKey = "request.id"
+)
+
+type ctxKey struct{}
+
+// Middleware reads or generates the request ID, echoes it in the response
+// header and stores it in both the gin and the request context.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = generate()
+		}
+		c.Set(CtxKey, id)
+		c.Header(Header, id)
+		c.Request = c.Request.WithContext(With(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// With returns a copy of ctx carrying id.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// From returns the request ID carried by ctx, or "".
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Get returns the request ID of c, or "".
+func Get(c *gin.Context) string { return c.GetString(CtxKey) }
+
+// valid accepts up to 128 printable ASCII characters, so a client-chosen ID
+// can't smuggle anything odd into logs or headers.
+func valid(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func generate() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
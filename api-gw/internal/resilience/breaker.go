@@ -0,0 +1,135 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// BreakerConfig: the breaker opens after Failures consecutive backend
+// failures, fast-fails for OpenFor, then lets a single probe through.
+type BreakerConfig struct {
+	Failures int
+	OpenFor  time.Duration
+}
+
+// BreakerStatus is a snapshot for status endpoints.
+type BreakerStatus struct {
+	Name        string     `json:"name"`
+	State       string     `json:"state"`
+	Failures    int        `json:"consecutive_failures"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	RetryAfter  float64    `json:"retry_after_seconds,omitempty"`
+	TotalOpened int64      `json:"total_opened"`
+	Rejected    int64      `json:"rejected"`
+}
+
+type Breaker struct {
+	name string
+	cfg  BreakerConfig
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool // a half-open probe is in flight
+	opened   int64
+	rejected int64
+}
+
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	if cfg.Failures <= 0 {
+		cfg.Failures = 5
+	}
+	if cfg.OpenFor <= 0 {
+		cfg.OpenFor = 10 * time.Second
+	}
+	return &Breaker{name: name, cfg: cfg}
+}
+
+// allow reports whether a call may proceed; when it may not, it also
+// returns how long until the breaker will let a probe through.
+func (b *Breaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Open:
+		if wait := b.cfg.OpenFor - time.Since(b.openedAt); wait > 0 {
+			b.rejected++
+			return false, wait
+		}
+		b.state = HalfOpen
+		fallthrough
+	case HalfOpen:
+		if b.probing {
+			b.rejected++
+			return false, time.Second
+		}
+		b.probing = true
+	}
+	return true, 0
+}
+
+type outcome int
+
+const (
+	succeeded outcome = iota // the backend answered (possibly with a domain error)
+	failed                   // the backend is unhealthy
+	unknown                  // the caller gave up; says nothing about the backend
+)
+
+// record feeds the outcome of an allowed call back into the breaker.
+func (b *Breaker) record(o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	switch o {
+	case unknown:
+		return
+	case succeeded:
+		b.state, b.failures = Closed, 0
+		return
+	}
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.cfg.Failures {
+		if b.state != Open {
+			b.opened++
+		}
+		b.state, b.openedAt = Open, time.Now()
+	}
+}
+
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := BreakerStatus{
+		Name:        b.name,
+		State:       b.state.String(),
+		Failures:    b.failures,
+		TotalOpened: b.opened,
+		Rejected:    b.rejected,
+	}
+	if b.state == Open {
+		openedAt := b.openedAt
+		st.OpenedAt = &openedAt
+		st.RetryAfter = max(b.cfg.OpenFor-time.Since(b.openedAt), 0).Seconds()
+	}
+	return st
+}
@@ -0,0 +1,84 @@
+package resilience
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+var (
+	admissionWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "harmonia_admission_wait_seconds",
+		Help: "Time calls spent queued for a backend concurrency slot.",
+	}, []string{"backend"})
+	admissionShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "harmonia_admission_shed_total",
+		Help: "Calls shed by a backend concurrency limiter.",
+	}, []string{"backend", "reason"})
+)
+
+// LimiterConfig bounds concurrent calls to a backend. Up to MaxQueue further
+// calls wait at most QueueWait for a slot; the rest are shed at once.
+// MaxInFlight <= 0 disables the limiter.
+type LimiterConfig struct {
+	MaxInFlight int
+	MaxQueue    int
+	QueueWait   time.Duration
+}
+
+// Limiter is a counting semaphore with a bounded wait queue.
+type Limiter struct {
+	name   string
+	cfg    LimiterConfig
+	slots  chan struct{}
+	queued atomic.Int64
+}
+
+func NewLimiter(name string, cfg LimiterConfig) *Limiter {
+	l := &Limiter{name: name, cfg: cfg}
+	if cfg.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, cfg.MaxInFlight)
+	}
+	return l
+}
+
+// Acquire takes a slot, queueing if allowed; the returned func releases it.
+// It fails with rpcerr.Overloaded when the queue is full or the wait runs
+// out, and with the context's error if the caller gives up first.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	if l == nil || l.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-l.slots }
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if l.queued.Add(1) > int64(l.cfg.MaxQueue) {
+		l.queued.Add(-1)
+		admissionShed.WithLabelValues(l.name, "queue_full").Inc()
+		return nil, rpcerr.Overload(l.name, time.Second)
+	}
+	defer l.queued.Add(-1)
+
+	start := time.Now()
+	t := time.NewTimer(l.cfg.QueueWait)
+	defer t.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		admissionWait.WithLabelValues(l.name).Observe(time.Since(start).Seconds())
+		return release, nil
+	case <-t.C:
+		admissionShed.WithLabelValues(l.name, "queue_timeout").Inc()
+		return nil, rpcerr.Overload(l.name, time.Second)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
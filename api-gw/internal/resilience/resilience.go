@@ -0,0 +1,144 @@
+// Package resilience wraps backend RPCs with per-method retries (jittered
+// exponential backoff, bounded by the request deadline) and a circuit
+// breaker per backend.
+package resilience
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/logging"
+	"github.com/Patrick8894/harmonia/api-gw/internal/rpcerr"
+)
+
+var (
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "harmonia_rpc_duration_seconds",
+		Help: "Latency of individual backend RPC attempts.",
+	}, []string{"backend", "method"})
+	rpcErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "harmonia_rpc_errors_total",
+		Help: "Failed backend RPC attempts by error kind (circuit_open counts rejected calls).",
+	}, []string{"backend", "method", "kind"})
+	rpcRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "harmonia_rpc_retries_total",
+		Help: "Backend RPC attempts beyond the first.",
+	}, []string{"backend", "method"})
+)
+
+// RetryPolicy: Attempts counts the first try, so 1 disables retries.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// NoRetry is the policy for calls that must not be repeated.
+var NoRetry = RetryPolicy{Attempts: 1}
+
+type Config struct {
+	Retry   RetryPolicy
+	Breaker BreakerConfig
+}
+
+// Executor runs the calls of one backend.
+type Executor struct {
+	breaker *Breaker
+	retry   RetryPolicy
+	methods map[string]RetryPolicy
+}
+
+// New builds an executor for backend name. methods overrides the default
+// retry policy per RPC method; pass NoRetry for non-idempotent methods.
+func New(name string, cfg Config, methods map[string]RetryPolicy) *Executor {
+	return &Executor{breaker: NewBreaker(name, cfg.Breaker), retry: cfg.Retry, methods: methods}
+}
+
+func (e *Executor) Breaker() *Breaker { return e.breaker }
+
+// Do runs fn under the breaker, retrying failures that are safe to retry
+// (the backend was unreachable) while the context's deadline allows.
+func (e *Executor) Do(ctx context.Context, method string, fn func(context.Context) error) error {
+	pol, ok := e.methods[method]
+	if !ok {
+		pol = e.retry
+	}
+	attempts := max(pol.Attempts, 1)
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if !sleep(ctx, backoff(pol, attempt)) {
+				return err
+			}
+		}
+		allowed, wait := e.breaker.allow()
+		if !allowed {
+			rpcErrors.WithLabelValues(e.breaker.name, method, rpcerr.Open.String()).Inc()
+			return rpcerr.CircuitOpen(e.breaker.name, wait)
+		}
+		if attempt > 0 {
+			rpcRetries.WithLabelValues(e.breaker.name, method).Inc()
+		}
+		start := time.Now()
+		err = fn(ctx)
+		took := time.Since(start)
+		rpcDuration.WithLabelValues(e.breaker.name, method).Observe(took.Seconds())
+		logging.RecordRPC(ctx, e.breaker.name, method, took, err)
+		if err != nil {
+			rpcErrors.WithLabelValues(e.breaker.name, method, rpcerr.KindOf(err).String()).Inc()
+		}
+		e.breaker.record(classify(err))
+		if err == nil || rpcerr.KindOf(err) != rpcerr.Unavailable {
+			return err
+		}
+	}
+	return err
+}
+
+// classify maps a call result to a breaker outcome: only errors that say
+// something about backend health trip the breaker.
+func classify(err error) outcome {
+	if err == nil {
+		return succeeded
+	}
+	switch rpcerr.KindOf(err) {
+	case rpcerr.Invalid:
+		return succeeded
+	case rpcerr.Canceled:
+		return unknown
+	}
+	return failed
+}
+
+// backoff is "full jitter": a random delay in [0, min(max, base*2^n)).
+func backoff(pol RetryPolicy, attempt int) time.Duration {
+	d := pol.BaseDelay << (attempt - 1)
+	if d <= 0 || (pol.MaxDelay > 0 && d > pol.MaxDelay) {
+		d = pol.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// sleep waits d unless the context ends first or its deadline would pass
+// before the next attempt could start.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if dl, ok := ctx.Deadline(); ok && time.Until(dl) <= d {
+		return false
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
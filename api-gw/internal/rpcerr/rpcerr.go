@@ -0,0 +1,212 @@
+// Package rpcerr classifies failures of backend RPCs so handlers can answer
+// with a status that says whose fault it was: the caller's input (422, or
+// 413 when it is too big to admit), a slow backend (504), a busy one (503) or
+// a broken one (502).
+package rpcerr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/logging"
+	"github.com/Patrick8894/harmonia/api-gw/internal/requestid"
+)
+
+type Kind int
+
+const (
+	Internal    Kind = iota // backend failed in an unexpected way
+	Invalid                 // deterministic domain error, e.g. a bad expression
+	Unavailable             // backend unreachable or the connection broke
+	Timeout                 // deadline exceeded waiting for the backend
+	Canceled                // the caller went away
+	Open                    // circuit breaker is open; not even tried
+	TooLarge                // request exceeds a size limit; not even tried
+	TooCostly               // request exceeds a cost limit; not even tried
+	Overloaded              // too many expensive calls in flight; shed
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Invalid:
+		return "invalid_input"
+	case Unavailable:
+		return "backend_unavailable"
+	case Timeout:
+		return "backend_timeout"
+	case Canceled:
+		return "canceled"
+	case Open:
+		return "circuit_open"
+	case TooLarge:
+		return "too_large"
+	case TooCostly:
+		return "too_costly"
+	case Overloaded:
+		return "overloaded"
+	}
+	return "backend_error"
+}
+
+// ParseKind is the inverse of Kind.String; unknown names are Internal.
+func ParseKind(s string) Kind {
+	for k := Internal; k <= Overloaded; k++ {
+		if k.String() == s {
+			return k
+		}
+	}
+	return Internal
+}
+
+// Status is the HTTP status reported for errors of this kind.
+func (k Kind) Status() int {
+	switch k {
+	case Invalid, TooCostly:
+		return http.StatusUnprocessableEntity
+	case TooLarge:
+		return http.StatusRequestEntityTooLarge
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case Canceled:
+		return 499 // client closed request
+	case Open, Overloaded:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+// Error is a classified backend failure.
+type Error struct {
+	Kind    Kind
+	Backend string // "engine" | "logic"
+	Msg     string
+	Err     error
+
+	RetryAfter time.Duration // Open and Overloaded: when to try again
+}
+
+func (e *Error) Error() string {
+	switch e.Kind {
+	case Invalid, TooLarge, TooCostly:
+		return e.Msg
+	}
+	if e.Err != nil {
+		return e.Backend + ": " + e.Msg + ": " + e.Err.Error()
+	}
+	return e.Backend + ": " + e.Msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Domain reports a deterministic error returned in a reply body.
+func Domain(backend, msg string) error {
+	return &Error{Kind: Invalid, Backend: backend, Msg: msg}
+}
+
+// CircuitOpen reports a call rejected by an open circuit breaker.
+func CircuitOpen(backend string, retryAfter time.Duration) error {
+	return &Error{Kind: Open, Backend: backend, Msg: "circuit open", RetryAfter: retryAfter}
+}
+
+// Rejected reports a request refused by admission control (TooLarge or
+// TooCostly) before any backend call.
+func Rejected(backend string, k Kind, msg string) error {
+	return &Error{Kind: k, Backend: backend, Msg: msg}
+}
+
+// Overload reports a call shed because the backend's concurrency limit and
+// queue were full.
+func Overload(backend string, retryAfter time.Duration) error {
+	return &Error{Kind: Overloaded, Backend: backend, Msg: "too many requests in flight", RetryAfter: retryAfter}
+}
+
+// KindOf returns the kind of a classified error, or classifies err on the fly
+// (context errors); anything else counts as Internal.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	}
+	return Internal
+}
+
+// FromGRPC classifies an error returned by a gRPC stub.
+func FromGRPC(backend string, err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return wrapContext(backend, err)
+	}
+	e := &Error{Backend: backend, Msg: "rpc failed", Err: err}
+	switch st.Code() {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		e.Kind, e.Msg = Invalid, st.Message()
+	case codes.DeadlineExceeded:
+		e.Kind, e.Msg = Timeout, "deadline exceeded"
+	case codes.Canceled:
+		e.Kind, e.Msg = Canceled, "canceled"
+	case codes.Unavailable:
+		e.Kind, e.Msg = Unavailable, "unavailable"
+	default:
+		e.Kind = Internal
+	}
+	return e
+}
+
+// FromThrift classifies an error returned by a Thrift client call.
+func FromThrift(backend string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var te thrift.TTransportException
+	if errors.As(err, &te) {
+		switch {
+		case te.TypeId() == thrift.TIMED_OUT || errors.Is(err, context.DeadlineExceeded):
+			return &Error{Kind: Timeout, Backend: backend, Msg: "deadline exceeded", Err: err}
+		case errors.Is(err, context.Canceled):
+			return &Error{Kind: Canceled, Backend: backend, Msg: "canceled", Err: err}
+		}
+		return &Error{Kind: Unavailable, Backend: backend, Msg: "unavailable", Err: err}
+	}
+	return wrapContext(backend, err)
+}
+
+func wrapContext(backend string, err error) error {
+	switch k := KindOf(err); k {
+	case Timeout:
+		return &Error{Kind: k, Backend: backend, Msg: "deadline exceeded", Err: err}
+	case Canceled:
+		return &Error{Kind: k, Backend: backend, Msg: "canceled", Err: err}
+	}
+	return &Error{Kind: Internal, Backend: backend, Msg: "rpc failed", Err: err}
+}
+
+// Respond writes err as a JSON error body with the status for its kind,
+// plus Retry-After when a circuit breaker or load shedding rejected the call.
+func Respond(c *gin.Context, err error) {
+	k := KindOf(err)
+	var e *Error
+	if errors.As(err, &e) && e.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	logging.Annotate(c.Request.Context(), slog.String("error_kind", k.String()))
+	c.JSON(k.Status(), gin.H{"error": err.Error(), "kind": k.String(), "request_id": requestid.Get(c)})
+}
@@ -0,0 +1,110 @@
+// Package tracing sets up OpenTelemetry for the gateway and provides the
+// Gin middleware that opens a server span per request.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Patrick8894/harmonia/api-gw/internal/auth"
+)
+
+const scope = "github.com/Patrick8894/harmonia/api-gw/internal/tracing"
+
+// Config selects the span exporter.
+//
+// "otlp" sends spans over OTLP/HTTP; endpoint, headers and TLS come from the
+// standard OTEL_EXPORTER_OTLP_* variables. "stdout" pretty-prints spans for
+// local debugging. "none" (the default) keeps propagation working but
+// records nothing.
+type Config struct {
+	Exporter    string  // none | stdout | otlp
+	ServiceName string  // overridden by OTEL_SERVICE_NAME
+	SampleRatio float64 // fraction of new traces to sample; parents' decisions are kept
+}
+
+// Setup installs the global tracer provider and the W3C trace-context
+// propagator. The returned function flushes pending spans.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	if env, err := resource.New(ctx, resource.WithFromEnv()); err == nil {
+		res, _ = resource.Merge(res, env)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Middleware continues the caller's trace (traceparent header) or starts a
+// new one, and wraps the request in a server span named after the route
+// template.
+func Middleware() gin.HandlerFunc {
+	tracer := otel.Tracer(scope)
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+			))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if user := c.GetString(auth.CtxUserKey); user != "" {
+			span.SetAttributes(attribute.String("enduser.id", user))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
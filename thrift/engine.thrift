@@ -34,6 +34,9 @@ struct VectorStatsReply {
   7: double max
 }
 
+// The gateway appends the caller's W3C traceparent as field 32767 (string)
+// of every call's argument struct. Servers skip it as an unknown field; to
+// read it, declare "32767: string traceparent" as a trailing parameter.
 service EngineService {
   HelloReply Hello(1: HelloRequest req)
   PiReply    EstimatePi(1: PiRequest req)